@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-fluid-topics/pkg/connector"
+	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const bulkRolesConcurrencyFlag = "concurrency"
+
+// bulkRolesConfiguration holds the connector fields the bulk-roles subcommand needs to reach Fluid Topics, to
+// record its changes in the audit log and to honor the dry run and read-only modes.
+var bulkRolesConfiguration = field.Configuration{
+	Fields: []field.SchemaField{
+		bearerTokenField,
+		domainField,
+		dryRunField,
+		auditLogField,
+		readOnlyField,
+	},
+}
+
+// newBulkRolesCommand returns the bulk-roles subcommand, it applies the manual role changes of a CSV file.
+func newBulkRolesCommand(ctx context.Context, v *viper.Viper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bulk-roles <file.csv>",
+		Short: "Add or remove manual roles of many users from a CSV file",
+		Long: "Add or remove manual roles of many users from a CSV file with the columns user, role and action.\n" +
+			"The user is a user ID or an email, the action is add or remove. A header row is optional.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := v.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+
+			if v.GetBool(readOnlyField.FieldName) {
+				return errors.New("bulk-roles changes manual roles, it is disabled in read-only mode")
+			}
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			changes, err := readRoleChanges(file)
+			if err != nil {
+				return fmt.Errorf("error reading %s: %w", args[0], err)
+			}
+
+			dryRun := v.GetBool(dryRunField.FieldName)
+			concurrency := v.GetInt(bulkRolesConcurrencyFlag)
+
+			c, err := newBulkRolesClient(ctx, v, dryRun)
+			if err != nil {
+				return err
+			}
+
+			// When interrupted, the users already updated are still reported.
+			results, err := connector.ApplyBulkRoles(ctx, c, changes, concurrency, dryRun)
+			if results != nil {
+				if summaryErr := printBulkRolesSummary(cmd.OutOrStdout(), results, dryRun); err == nil {
+					err = summaryErr
+				}
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().Int(bulkRolesConcurrencyFlag, 4, "Number of users updated at the same time")
+
+	return cmd
+}
+
+// newBulkRolesClient returns the client applying the role changes, recording them in the audit log when one is set.
+// In read-only mode the client refuses the changes.
+func newBulkRolesClient(ctx context.Context, v *viper.Viper, dryRun bool) (*client.FluidTopicsClient, error) {
+	opts := []client.Option{client.WithDryRun(dryRun), client.WithReadOnly(v.GetBool(readOnlyField.FieldName))}
+
+	if path := v.GetString(auditLogField.FieldName); path != "" {
+		auditLog, err := client.NewAuditLog(path)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithAuditLog(auditLog))
+	}
+
+	return client.New(ctx, v.GetString(bearerTokenField.FieldName), v.GetString(domainField.FieldName), opts...)
+}
+
+// readRoleChanges reads the user, role and action columns of the CSV, skipping the header row when there is one.
+func readRoleChanges(r io.Reader) ([]connector.RoleChange, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var changes []connector.RoleChange
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 && strings.EqualFold(record[2], "action") {
+			continue
+		}
+
+		changes = append(changes, connector.RoleChange{
+			User:   strings.TrimSpace(record[0]),
+			Role:   strings.TrimSpace(record[1]),
+			Action: strings.TrimSpace(record[2]),
+		})
+	}
+
+	return changes, nil
+}
+
+// printBulkRolesSummary prints a line per user, then the totals, and fails when a user could not be updated.
+func printBulkRolesSummary(w io.Writer, results []connector.BulkRolesResult, dryRun bool) error {
+	applied, skipped, failed := 0, 0, 0
+	verb := "applied"
+	if dryRun {
+		verb = "would apply"
+	}
+
+	for _, result := range results {
+		user := result.User
+		if result.UserID != "" && result.UserID != result.User {
+			user = fmt.Sprintf("%s (%s)", result.User, result.UserID)
+		}
+
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Fprintf(w, "failed      %s: %s\n", user, result.Err)
+		case result.Skipped != "":
+			skipped++
+			fmt.Fprintf(w, "skipped     %s: %s\n", user, result.Skipped)
+		default:
+			applied++
+			fmt.Fprintf(w, "%-11s %s: added %s, removed %s\n", verb, user, listOrNone(result.Added), listOrNone(result.Removed))
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d users %s, %d skipped, %d failed\n", applied, verb, skipped, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d users could not be updated", failed)
+	}
+	return nil
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
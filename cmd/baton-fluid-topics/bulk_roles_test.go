@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-fluid-topics/pkg/connector"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRoleChanges(t *testing.T) {
+	changes, err := readRoleChanges(strings.NewReader("user,role,action\njane@x.com, PRINT_USER, add\nuser-2,ADMIN,remove\n"))
+	require.NoError(t, err)
+	require.Equal(t, []connector.RoleChange{
+		{User: "jane@x.com", Role: "PRINT_USER", Action: "add"},
+		{User: "user-2", Role: "ADMIN", Action: "remove"},
+	}, changes)
+
+	_, err = readRoleChanges(strings.NewReader("jane@x.com,PRINT_USER\n"))
+	require.Error(t, err)
+}
+
+func TestBulkRolesClientAuditLog(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	v := viper.New()
+	v.Set(bearerTokenField.FieldName, "token")
+	v.Set(domainField.FieldName, "https://example.fluidtopics.net")
+	v.Set(auditLogField.FieldName, path)
+
+	c, err := newBulkRolesClient(ctx, v, true)
+	require.NoError(t, err)
+	_, err = c.UpdateUserManualRoles(client.WithAuditManualRolesBefore(ctx, []string{"ADMIN"}), "user-1", []string{"PRINT_USER"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"targetUser":"user-1"`)
+	require.Contains(t, string(data), `"manualRoles":{"before":["ADMIN"],"after":["PRINT_USER"]}`)
+	require.Contains(t, string(data), `"outcome":"dry_run"`)
+}
+
+func TestBulkRolesReadOnly(t *testing.T) {
+	ctx := context.Background()
+
+	v := viper.New()
+	v.Set(bearerTokenField.FieldName, "token")
+	v.Set(domainField.FieldName, "https://example.fluidtopics.net")
+	v.Set(readOnlyField.FieldName, true)
+
+	c, err := newBulkRolesClient(ctx, v, false)
+	require.NoError(t, err)
+	_, err = c.UpdateUserManualRoles(ctx, "user-1", []string{"PRINT_USER"})
+	require.ErrorContains(t, err, "read-only mode")
+
+	path := filepath.Join(t.TempDir(), "roles.csv")
+	require.NoError(t, os.WriteFile(path, []byte("user-1,PRINT_USER,add\n"), 0o600))
+	cmd := newBulkRolesCommand(ctx, v)
+	cmd.SetArgs([]string{path})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	require.ErrorContains(t, cmd.Execute(), "disabled in read-only mode")
+}
+
+func TestBulkRolesDryRunFlag(t *testing.T) {
+	cmd := newBulkRolesCommand(context.Background(), viper.New())
+
+	// The dry run flag is the connector one, bulk-roles does not define its own.
+	require.Nil(t, cmd.Flags().Lookup(dryRunField.FieldName))
+	require.Contains(t, bulkRolesConfiguration.Fields, dryRunField)
+}
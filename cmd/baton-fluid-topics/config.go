@@ -16,12 +16,48 @@ var (
 		field.WithDescription("Base domain for API, e.g. https://example.fluidtopics.net"),
 		field.WithRequired(true),
 	)
+	userActivityMetricsField = field.BoolField(
+		"user-activity-metrics",
+		field.WithDescription("Add the number of documents read, searches, exports and generative AI queries of the last 30 and 90 days to the user profiles"),
+	)
+	incrementalSyncStateField = field.StringField(
+		"incremental-sync-state",
+		field.WithDescription("Path of the file keeping the state of the previous sync, when set the data dump of a user is only fetched again when its listing changed, the roles of every user are still fetched"),
+	)
+	fullResyncIntervalField = field.IntField(
+		"full-resync-interval-hours",
+		field.WithDescription("Number of hours after which an incremental sync fetches all the users again"),
+		field.WithDefaultValue(168),
+	)
+	revokeSessionsOnDeleteField = field.BoolField(
+		"revoke-sessions-on-delete",
+		field.WithDescription("Revoke the sessions of a user before erase_user or merge_users deletes its account, so it is signed out right away"),
+	)
+	dryRunField = field.BoolField(
+		"dry-run",
+		field.WithDescription("Log the changes to Fluid Topics instead of making them, reads are still made"),
+	)
+	auditLogField = field.StringField(
+		"audit-log",
+		field.WithDescription("Path of the JSONL file every request changing Fluid Topics is appended to, with credentials redacted"),
+	)
+	readOnlyField = field.BoolField(
+		"read-only",
+		field.WithDescription("Only sync, never change Fluid Topics: provisioning and the custom actions changing it are disabled"),
+	)
 	// ConfigurationFields defines the external configuration required for the
 	// connector to run. Note: these fields can be marked as optional or
 	// required.
 	ConfigurationFields = []field.SchemaField{
 		bearerTokenField,
 		domainField,
+		userActivityMetricsField,
+		incrementalSyncStateField,
+		fullResyncIntervalField,
+		revokeSessionsOnDeleteField,
+		dryRunField,
+		auditLogField,
+		readOnlyField,
 	}
 
 	// FieldRelationships defines relationships between the fields listed in
@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/conductorone/baton-fluid-topics/pkg/connector"
+	"github.com/conductorone/baton-sdk/pkg/cli"
 	"github.com/conductorone/baton-sdk/pkg/config"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/conductorone/baton-sdk/pkg/metrics"
 	"github.com/conductorone/baton-sdk/pkg/types"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 )
 
@@ -20,7 +24,7 @@ var version = "dev"
 func main() {
 	ctx := context.Background()
 
-	_, cmd, err := config.DefineConfiguration(
+	v, cmd, err := config.DefineConfiguration(
 		ctx,
 		"baton-fluid-topics",
 		getConnector,
@@ -35,6 +39,18 @@ func main() {
 
 	cmd.Version = version
 
+	_, err = cli.AddCommand(cmd, v, &bulkRolesConfiguration, newBulkRolesCommand(ctx, v))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	_, err = cli.AddCommand(cmd, v, &reportConfiguration, newReportCommand(ctx, v))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
 	err = cmd.Execute()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
@@ -51,12 +67,30 @@ func getConnector(ctx context.Context, v *viper.Viper) (types.ConnectorServer, e
 	fluidTopicsBearerToken := v.GetString(bearerTokenField.FieldName)
 	fluidTopicsDomain := v.GetString(domainField.FieldName)
 
-	cb, err := connector.New(ctx, fluidTopicsBearerToken, fluidTopicsDomain)
+	// The metrics go to the global meter provider. The SDK only sets up the OTel traces and logs, so the metrics are
+	// dropped unless the process embedding the connector sets a meter provider.
+	metricsHandler := metrics.NewOtelHandler(ctx, otel.GetMeterProvider(), "baton-fluid-topics")
+
+	cb, err := connector.New(
+		ctx,
+		fluidTopicsBearerToken,
+		fluidTopicsDomain,
+		connector.WithUserActivityMetrics(v.GetBool(userActivityMetricsField.FieldName)),
+		connector.WithIncrementalSync(
+			v.GetString(incrementalSyncStateField.FieldName),
+			time.Duration(v.GetInt(fullResyncIntervalField.FieldName))*time.Hour,
+		),
+		connector.WithRevokeSessionsOnDelete(v.GetBool(revokeSessionsOnDeleteField.FieldName)),
+		connector.WithDryRun(v.GetBool(dryRunField.FieldName)),
+		connector.WithMetricsHandler(metricsHandler),
+		connector.WithAuditLog(v.GetString(auditLogField.FieldName)),
+		connector.WithReadOnly(v.GetBool(readOnlyField.FieldName)),
+	)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
 	}
-	newC, err := connectorbuilder.NewConnector(ctx, cb)
+	newC, err := connectorbuilder.NewConnector(ctx, cb, connectorbuilder.WithMetricsHandler(metricsHandler))
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-fluid-topics/pkg/connector"
+	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	reportFormatFlag      = "format"
+	reportOutputFlag      = "output"
+	reportDormantDaysFlag = "dormant-days"
+)
+
+// reportConfiguration holds the connector fields the report subcommand needs to reach Fluid Topics.
+var reportConfiguration = field.Configuration{
+	Fields: []field.SchemaField{
+		bearerTokenField,
+		domainField,
+	},
+}
+
+// newReportCommand returns the report subcommand, it writes the access review report of the tenant.
+func newReportCommand(ctx context.Context, v *viper.Viper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Write the users and roles access review report as CSV, JSON or HTML",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := v.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+
+			format := v.GetString(reportFormatFlag)
+			write, ok := reportWriters[format]
+			if !ok {
+				return fmt.Errorf("unsupported report format %q, expected csv, json or html", format)
+			}
+
+			c, err := client.New(ctx, v.GetString(bearerTokenField.FieldName), v.GetString(domainField.FieldName))
+			if err != nil {
+				return err
+			}
+
+			dormantAfter := time.Duration(v.GetInt(reportDormantDaysFlag)) * 24 * time.Hour
+			report, err := connector.BuildAccessReport(ctx, c, time.Now(), dormantAfter)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if path := v.GetString(reportOutputFlag); path != "" {
+				file, err := os.Create(path)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				out = file
+			}
+
+			return write(out, report)
+		},
+	}
+
+	cmd.Flags().String(reportFormatFlag, "csv", "Format of the report: csv, json or html")
+	cmd.Flags().String(reportOutputFlag, "", "Path of the report file, the report is written to the standard output when empty")
+	cmd.Flags().Int(reportDormantDaysFlag, 90, "Number of days without activity after which an administrator is dormant")
+
+	return cmd
+}
+
+var reportWriters = map[string]func(io.Writer, *connector.AccessReport) error{
+	"csv":  writeCSVReport,
+	"json": writeJSONReport,
+	"html": writeHTMLReport,
+}
+
+func writeCSVReport(w io.Writer, report *connector.AccessReport) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{
+		"user_id",
+		"name",
+		"email",
+		"realm",
+		"last_activity",
+		"manual_roles",
+		"authentication_roles",
+		"default_roles",
+		"admin_roles",
+		"dormant_admin",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, user := range report.Users {
+		err := writer.Write([]string{
+			user.Id,
+			user.Name,
+			user.Email,
+			user.Realm,
+			formatLastActivity(user.LastActivity),
+			strings.Join(user.ManualRoles, ";"),
+			strings.Join(user.AuthenticationRoles, ";"),
+			strings.Join(user.DefaultRoles, ";"),
+			strings.Join(user.AdminRoles, ";"),
+			fmt.Sprint(user.Dormant),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeJSONReport(w io.Writer, report *connector.AccessReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func writeHTMLReport(w io.Writer, report *connector.AccessReport) error {
+	return reportTemplate.Execute(w, report)
+}
+
+func formatLastActivity(lastActivity time.Time) string {
+	if lastActivity.IsZero() {
+		return ""
+	}
+	return lastActivity.Format(time.RFC3339)
+}
+
+// reportTemplate renders a self-contained page, without any external stylesheet or script.
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lastActivity": func(lastActivity time.Time) string {
+		if lastActivity.IsZero() {
+			return "never"
+		}
+		return lastActivity.Format("2006-01-02")
+	},
+	"isAdmin": func(user connector.AccessReportUser, role string) bool {
+		return slices.Contains(user.AdminRoles, role)
+	},
+	"days": func(d time.Duration) int {
+		return int(d.Hours() / 24)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Fluid Topics access review</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f2f2f2; }
+.admin { color: #a00; font-weight: bold; }
+tr.dormant { background: #fff0f0; }
+.role { display: inline-block; margin-right: 0.4em; }
+</style>
+</head>
+<body>
+<h1>Fluid Topics access review</h1>
+<p>Generated on {{ .GeneratedAt.Format "2006-01-02 15:04 MST" }}, {{ len .Users }} users.</p>
+
+<h2>Dormant administrators</h2>
+{{ with .DormantAdmins }}
+<p>Administrators without activity for more than {{ days $.DormantAfter }} days.</p>
+<table>
+<tr><th>User</th><th>Email</th><th>Realm</th><th>Last activity</th><th>Admin roles</th></tr>
+{{ range . }}<tr class="dormant"><td>{{ .Name }}</td><td>{{ .Email }}</td><td>{{ .Realm }}</td><td>{{ lastActivity .LastActivity }}</td><td class="admin">{{ range .AdminRoles }}<span class="role">{{ . }}</span>{{ end }}</td></tr>
+{{ end }}</table>
+{{ else }}
+<p>No dormant administrator.</p>
+{{ end }}
+
+<h2>Users</h2>
+<table>
+<tr><th>User</th><th>Email</th><th>Realm</th><th>Last activity</th><th>Manual roles</th><th>Authentication roles</th><th>Default roles</th></tr>
+{{ range $user := .Users }}<tr{{ if .Dormant }} class="dormant"{{ end }}><td>{{ .Name }}</td><td>{{ .Email }}</td><td>{{ .Realm }}</td><td>{{ lastActivity .LastActivity }}</td>
+<td>{{ range .ManualRoles }}<span class="role{{ if isAdmin $user . }} admin{{ end }}">{{ . }}</span>{{ end }}</td>
+<td>{{ range .AuthenticationRoles }}<span class="role{{ if isAdmin $user . }} admin{{ end }}">{{ . }}</span>{{ end }}</td>
+<td>{{ range .DefaultRoles }}<span class="role{{ if isAdmin $user . }} admin{{ end }}">{{ . }}</span>{{ end }}</td></tr>
+{{ end }}</table>
+
+<h2>Roles</h2>
+<table>
+<tr><th>Role</th><th>Description</th></tr>
+{{ range .Roles }}<tr><td{{ if .Admin }} class="admin"{{ end }}>{{ .Name }}</td><td>{{ .Description }}</td></tr>
+{{ end }}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/connector"
+	"github.com/stretchr/testify/require"
+)
+
+func testAccessReport() *connector.AccessReport {
+	return &connector.AccessReport{
+		GeneratedAt:  time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		DormantAfter: 90 * 24 * time.Hour,
+		Roles: []connector.AccessReportRole{
+			{Name: "ADMIN", Description: "Administrator with full access", Admin: true},
+			{Name: "PRINT_USER", Description: "Can print"},
+		},
+		Users: []connector.AccessReportUser{
+			{
+				Id:           "u1",
+				Name:         "Jane <Admin>",
+				Email:        "jane@x.com",
+				Realm:        "okta",
+				LastActivity: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+				ManualRoles:  []string{"ADMIN", "PRINT_USER"},
+				AdminRoles:   []string{"ADMIN"},
+				Dormant:      true,
+			},
+			{
+				Id:           "u2",
+				Name:         "John",
+				Realm:        "internal",
+				DefaultRoles: []string{"PRINT_USER"},
+			},
+		},
+	}
+}
+
+func TestWriteCSVReport(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeCSVReport(&out, testAccessReport()))
+
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, []string{"u1", "Jane <Admin>", "jane@x.com", "okta", "2026-05-01T00:00:00Z", "ADMIN;PRINT_USER", "", "", "ADMIN", "true"}, records[1])
+	require.Equal(t, []string{"u2", "John", "", "internal", "", "", "", "PRINT_USER", "", "false"}, records[2])
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeHTMLReport(&out, testAccessReport()))
+
+	page := out.String()
+	require.Contains(t, page, "Administrators without activity for more than 90 days")
+	require.Contains(t, page, `<span class="role admin">ADMIN</span>`)
+	require.Contains(t, page, `<span class="role">PRINT_USER</span>`)
+	require.Contains(t, page, "Jane &lt;Admin&gt;")
+	require.NotContains(t, page, "<script")
+	require.NotContains(t, page, "<link")
+}
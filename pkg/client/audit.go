@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// AuditOutcomeSuccess is the outcome of the requests Fluid Topics accepted.
+	AuditOutcomeSuccess = "success"
+	// AuditOutcomeFailure is the outcome of the requests that failed, Fluid Topics may not have received them.
+	AuditOutcomeFailure = "failure"
+	// AuditOutcomeDryRun is the outcome of the requests not sent because of the dry run.
+	AuditOutcomeDryRun = "dry_run"
+)
+
+// AuditTask describes the baton task a request is made for.
+type AuditTask struct {
+	Type     string `json:"type"`
+	Resource string `json:"resource,omitempty"`
+	// TraceId is the OpenTelemetry trace of the task, when the SDK propagates one.
+	TraceId string `json:"traceId,omitempty"`
+}
+
+// AuditManualRoles are the manual roles of the target user before and after a roles update.
+type AuditManualRoles struct {
+	// Before is null when the caller of the update did not pass them with WithAuditManualRolesBefore.
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
+// AuditEntry is a line of the audit log, for a request changing Fluid Topics.
+type AuditEntry struct {
+	Timestamp   time.Time         `json:"timestamp"`
+	Task        *AuditTask        `json:"task,omitempty"`
+	Method      string            `json:"method"`
+	Endpoint    string            `json:"endpoint"`
+	Path        string            `json:"path"`
+	TargetUser  string            `json:"targetUser,omitempty"`
+	ManualRoles *AuditManualRoles `json:"manualRoles,omitempty"`
+	// Body is the request body with its credentials redacted.
+	Body       json.RawMessage `json:"body,omitempty"`
+	Outcome    string          `json:"outcome"`
+	StatusCode int             `json:"statusCode,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// AuditLog appends an entry for each request changing Fluid Topics to a JSONL file.
+type AuditLog struct {
+	mtx sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// NewAuditLog returns an audit log appending to the file at path, created when missing.
+func NewAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log: %w", err)
+	}
+
+	return newAuditLog(file), nil
+}
+
+func newAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{
+		w:   w,
+		now: time.Now,
+	}
+}
+
+// WithAuditLog records every request changing Fluid Topics in the audit log.
+func WithAuditLog(a *AuditLog) Option {
+	return func(c *FluidTopicsClient) {
+		c.audit = a
+	}
+}
+
+type auditTaskKey struct{}
+
+type auditManualRolesKey struct{}
+
+type auditManualRolesBeforeKey struct{}
+
+// WithAuditTask returns a context whose requests are recorded in the audit log as made for the task.
+func WithAuditTask(ctx context.Context, taskType string, resource string) context.Context {
+	return context.WithValue(ctx, auditTaskKey{}, AuditTask{Type: taskType, Resource: resource})
+}
+
+// WithAuditManualRolesBefore returns a context whose manual roles update is recorded in the audit log as replacing
+// the roles, which the caller read to compute the update.
+func WithAuditManualRolesBefore(ctx context.Context, roles []string) context.Context {
+	if roles == nil {
+		roles = []string{}
+	}
+	return context.WithValue(ctx, auditManualRolesBeforeKey{}, roles)
+}
+
+// withAuditManualRoles returns a context recording the manual roles of the user before and after the update in the
+// audit log.
+func withAuditManualRoles(ctx context.Context, manualRoles []string) context.Context {
+	roles := AuditManualRoles{After: manualRoles}
+	if roles.After == nil {
+		roles.After = []string{}
+	}
+	if before, ok := ctx.Value(auditManualRolesBeforeKey{}).([]string); ok {
+		roles.Before = before
+	}
+	return context.WithValue(ctx, auditManualRolesKey{}, roles)
+}
+
+// record appends the entry of the request to the audit log. The request is already made, so a failure to record it
+// is logged rather than returned.
+func (a *AuditLog) record(
+	ctx context.Context,
+	method string,
+	urlAddress *url.URL,
+	endpoint string,
+	body interface{},
+	resp *http.Response,
+	outcome string,
+	requestErr error,
+) {
+	entry := AuditEntry{
+		Timestamp:  a.now().UTC(),
+		Method:     method,
+		Endpoint:   endpoint,
+		Path:       urlAddress.EscapedPath(),
+		TargetUser: auditTargetUser(endpoint, urlAddress.EscapedPath(), body),
+		Outcome:    outcome,
+	}
+
+	if task, ok := ctx.Value(auditTaskKey{}).(AuditTask); ok {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			task.TraceId = spanContext.TraceID().String()
+		}
+		entry.Task = &task
+	}
+	if roles, ok := ctx.Value(auditManualRolesKey{}).(AuditManualRoles); ok {
+		entry.ManualRoles = &roles
+	}
+	if body != nil {
+		if redacted := redactedJSON(body); redacted != "" {
+			entry.Body = json.RawMessage(redacted)
+		}
+	}
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+	}
+	if requestErr != nil {
+		entry.Error = requestErr.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		ctxzap.Extract(ctx).Error("failed to encode the audit log entry", zap.Error(err))
+		return
+	}
+	line = append(line, '\n')
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if _, err := a.w.Write(line); err != nil {
+		ctxzap.Extract(ctx).Error("failed to write the audit log entry", zap.Error(err), zap.String("path", urlAddress.Path))
+	}
+}
+
+// auditTargetUser returns the ID of the user the request is about: the unescaped user ID of the escaped path, or the
+// email of the registered user.
+func auditTargetUser(endpoint string, path string, body interface{}) string {
+	if newUser, ok := body.(NewUserInfo); ok {
+		return newUser.EmailAddress
+	}
+
+	endpointSegments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	// The path holds the API base path before the endpoint.
+	offset := len(pathSegments) - len(endpointSegments)
+	if offset < 0 {
+		return ""
+	}
+
+	for i := 0; i+1 < len(endpointSegments); i++ {
+		if endpointSegments[i] == "users" && endpointSegments[i+1] == "%s" {
+			userID, err := url.PathUnescape(pathSegments[offset+i+1])
+			if err != nil {
+				return pathSegments[offset+i+1]
+			}
+			return userID
+		}
+	}
+	return ""
+}
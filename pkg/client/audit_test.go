@@ -0,0 +1,152 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditEntries(t *testing.T, data []byte) []AuditEntry {
+	var entries []AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry AuditEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAuditLogRecord(t *testing.T) {
+	var out bytes.Buffer
+	auditLog := newAuditLog(&out)
+	auditLog.now = func() time.Time { return time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC) }
+
+	ctx := WithAuditTask(context.Background(), "grant", "Role:manual:ADMIN:assigned")
+	ctx = withAuditManualRoles(WithAuditManualRolesBefore(ctx, []string{"PRINT_USER"}), []string{"PRINT_USER", "ADMIN"})
+
+	rolesURL, err := url.Parse("https://example.fluidtopics.net/api/users/user-1/roles")
+	require.NoError(t, err)
+	auditLog.record(ctx, http.MethodPut, rolesURL, getUserRolesById, map[string]interface{}{"manualRoles": []string{"PRINT_USER", "ADMIN"}},
+		&http.Response{StatusCode: http.StatusOK}, AuditOutcomeSuccess, nil)
+
+	registerURL, err := url.Parse("https://example.fluidtopics.net/api/users/register")
+	require.NoError(t, err)
+	auditLog.record(context.Background(), http.MethodPost, registerURL, createUser,
+		NewUserInfo{Name: "Jane", EmailAddress: "jane@x.com", Password: "s3cret"},
+		&http.Response{StatusCode: http.StatusConflict}, AuditOutcomeFailure, errors.New("user already exists"))
+
+	require.NotContains(t, out.String(), "s3cret")
+
+	entries := readAuditEntries(t, out.Bytes())
+	require.Len(t, entries, 2)
+
+	require.Equal(t, AuditEntry{
+		Timestamp:   time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Task:        &AuditTask{Type: "grant", Resource: "Role:manual:ADMIN:assigned"},
+		Method:      http.MethodPut,
+		Endpoint:    getUserRolesById,
+		Path:        "/api/users/user-1/roles",
+		TargetUser:  "user-1",
+		ManualRoles: &AuditManualRoles{Before: []string{"PRINT_USER"}, After: []string{"PRINT_USER", "ADMIN"}},
+		Body:        json.RawMessage(`{"manualRoles":["PRINT_USER","ADMIN"]}`),
+		Outcome:     AuditOutcomeSuccess,
+		StatusCode:  http.StatusOK,
+	}, entries[0])
+
+	require.Nil(t, entries[1].Task)
+	require.Equal(t, "jane@x.com", entries[1].TargetUser)
+	require.Equal(t, AuditOutcomeFailure, entries[1].Outcome)
+	require.Equal(t, http.StatusConflict, entries[1].StatusCode)
+	require.Equal(t, "user already exists", entries[1].Error)
+	require.Contains(t, string(entries[1].Body), RedactedValue)
+}
+
+func TestAuditLogRedactsCredentialKeys(t *testing.T) {
+	var out bytes.Buffer
+	auditLog := newAuditLog(&out)
+
+	realmURL, err := url.Parse("https://example.fluidtopics.net/api/admin/realms/okta/mapping-rules")
+	require.NoError(t, err)
+	auditLog.record(context.Background(), http.MethodPut, realmURL, getRealmMappingRules,
+		map[string]interface{}{"rules": []interface{}{}, "oidc": map[string]interface{}{"clientSecret": "s3cret", "accessToken": "s3cret"}},
+		&http.Response{StatusCode: http.StatusOK}, AuditOutcomeSuccess, nil)
+
+	require.NotContains(t, out.String(), "s3cret")
+	entries := readAuditEntries(t, out.Bytes())
+	require.Len(t, entries, 1)
+	require.JSONEq(t, `{"rules":[],"oidc":{"clientSecret":"[REDACTED]","accessToken":"[REDACTED]"}}`, string(entries[0].Body))
+}
+
+func TestAuditLogDryRun(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	auditLog, err := NewAuditLog(path)
+	require.NoError(t, err)
+
+	c, err := New(ctx, "token", "https://example.fluidtopics.net", WithDryRun(true), WithAuditLog(auditLog))
+	require.NoError(t, err)
+
+	_, err = c.CreateUser(WithAuditTask(ctx, "create_account", "jane@x.com"), NewUserInfo{Name: "Jane", EmailAddress: "jane@x.com", Password: "s3cret"})
+	require.NoError(t, err)
+	_, err = c.DeleteUser(ctx, "user-1")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "s3cret")
+
+	entries := readAuditEntries(t, data)
+	require.Len(t, entries, 2)
+	require.Equal(t, AuditOutcomeDryRun, entries[0].Outcome)
+	require.Equal(t, createUser, entries[0].Endpoint)
+	require.Equal(t, &AuditTask{Type: "create_account", Resource: "jane@x.com"}, entries[0].Task)
+	require.Equal(t, "jane@x.com", entries[0].TargetUser)
+	require.Equal(t, http.MethodDelete, entries[1].Method)
+	require.Equal(t, deleteUser, entries[1].Endpoint)
+	require.Equal(t, "user-1", entries[1].TargetUser)
+}
+
+func TestAuditLogManualRolesUpdate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	requests := 0
+	server, tlsOpt := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	auditLog, err := NewAuditLog(path)
+	require.NoError(t, err)
+	c, err := New(ctx, "token", server.URL, tlsOpt, WithAuditLog(auditLog))
+	require.NoError(t, err)
+
+	// The roles before the update come from the caller, they are not read again.
+	_, err = c.UpdateUserManualRoles(WithAuditManualRolesBefore(ctx, []string{"PRINT_USER"}), "user-1", []string{"PRINT_USER", "ADMIN"})
+	require.NoError(t, err)
+	require.Equal(t, 1, requests)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	entries := readAuditEntries(t, data)
+	require.Len(t, entries, 1)
+	require.Equal(t, &AuditManualRoles{Before: []string{"PRINT_USER"}, After: []string{"PRINT_USER", "ADMIN"}}, entries[0].ManualRoles)
+	require.Equal(t, AuditOutcomeSuccess, entries[0].Outcome)
+}
+
+func TestAuditTargetUser(t *testing.T) {
+	require.Equal(t, "user-1", auditTargetUser(anonymizeUser, "/api/admin/users/user-1/anonymize", nil))
+	require.Equal(t, "user-1", auditTargetUser(transferUserContent, "/api/users/user-1/collections/c-1/transfer", nil))
+	require.Empty(t, auditTargetUser(getRealmMappingRules, "/api/admin/realms/okta/mapping-rules", nil))
+}
@@ -2,13 +2,19 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/metrics"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
@@ -21,22 +27,128 @@ const (
 	getUserInfoById       = "/users/%s/dump"
 	getAuthenticationInfo = "/authentication/current-session"
 	createUser            = "/users/register"
+	getRealms             = "/admin/realms"
+	getRealmMappingRules  = "/admin/realms/%s/mapping-rules"
+	getAnalyticsEvents    = "/analytics/v1/events"
+	getUsersHistory       = "/admin/users/history"
+	getUsersActivity      = "/analytics/v1/users/activity"
+
+	// The user management web services of the Fluid Topics API Reference Guide, each under its method and path
+	// relative to /api. Every %s is a path segment, escaped with url.PathEscape.
+
+	// DELETE /users/{userId}
+	deleteUser = "/users/%s"
+	// DELETE /users/{userId}/{personal-books|collections|bookmarks|saved-searches}
+	deleteUserContent = "/users/%s/%s"
+	// POST /admin/users/{userId}/anonymize
+	anonymizeUser = "/admin/users/%s/anonymize"
+	// GET /users/{userId}/{personal-books|collections}
+	getUserContent = "/users/%s/%s"
+	// POST /users/{userId}/{personal-books|collections}/{itemId}/transfer
+	transferUserContent = "/users/%s/%s/%s/transfer"
+	// DELETE /users/{userId}/{personal-books|collections}/{itemId}/shares/{user|group}/{principalId}
+	deleteUserContentShare = "/users/%s/%s/%s/shares/%s/%s"
+	// GET /users/{userId}/saved-searches
+	getSavedSearches = "/users/%s/saved-searches"
+	// PUT /users/{userId}/saved-searches/{searchId}/alert
+	updateSavedSearchAlert = "/users/%s/saved-searches/%s/alert"
+	// POST /admin/users/{userId}/sessions/revoke
+	revokeUserSessions = "/admin/users/%s/sessions/revoke"
+	// GET and PUT /users/{userId}/groups
+	getUserGroupsById = "/users/%s/groups"
 )
 
+// readOnlyEndpoints are queried with POST requests that do not change anything in Fluid Topics. They are the only
+// exception to read-only mode refusing every request but GET, and a request path must match one of them exactly.
+var readOnlyEndpoints = []string{
+	getAnalyticsEvents,
+	getUsersHistory,
+	getUsersActivity,
+}
+
+// uncachedEndpoints are read before being replaced as a whole, so their reads must return the last write. The HTTP
+// cache of the SDK keeps GET responses and a write does not invalidate them, so it is cleared before these reads.
+var uncachedEndpoints = []string{
+	getUserRolesById,
+	getRealmMappingRules,
+	getUserGroupsById,
+}
+
 type FluidTopicsClient struct {
 	httpClient  *uhttp.BaseHttpClient
 	tokenSource oauth2.TokenSource
 	baseURL     string
+	apiPath     string
+	dryRun      bool
+	readOnly    bool
+	metrics     *requestMetrics
+	audit       *AuditLog
+	// tlsConfig replaces the TLS configuration of the HTTP client.
+	tlsConfig *tls.Config
+	// streamClient sends the requests whose response is streamed, the base client reads every response at once.
+	streamClient *http.Client
+}
+
+// Option configures an optional behavior of the client.
+type Option func(*FluidTopicsClient)
+
+// WithDryRun logs the requests changing Fluid Topics instead of sending them, and reports them as successful.
+// Reads are still sent.
+func WithDryRun(enabled bool) Option {
+	return func(c *FluidTopicsClient) {
+		c.dryRun = enabled
+	}
+}
+
+// WithMetricsHandler measures the requests made to Fluid Topics with the metrics handler: counts, latencies and
+// status codes per endpoint, retryable responses and the remaining rate limit.
+func WithMetricsHandler(h metrics.Handler) Option {
+	return func(c *FluidTopicsClient) {
+		c.metrics = newRequestMetrics(h)
+	}
 }
 
-func New(ctx context.Context, bearerToken string, domain string) (*FluidTopicsClient, error) {
+// WithTLSClientConfig makes the HTTP client use the TLS configuration, e.g. to trust a private certificate authority.
+func WithTLSClientConfig(config *tls.Config) Option {
+	return func(c *FluidTopicsClient) {
+		c.tlsConfig = config
+	}
+}
+
+// WithReadOnly refuses every request that could change Fluid Topics, before sending it.
+func WithReadOnly(enabled bool) Option {
+	return func(c *FluidTopicsClient) {
+		c.readOnly = enabled
+	}
+}
+
+func New(ctx context.Context, bearerToken string, domain string, opts ...Option) (*FluidTopicsClient, error) {
 	if !strings.HasPrefix(domain, "https://") {
 		return nil, fmt.Errorf("domain must start with http://")
 	}
 	domain = strings.TrimRight(domain, "/")
 	baseURL := fmt.Sprintf("%s/api", domain)
 
-	httpClient, err := uhttp.NewClient(ctx, uhttp.WithLogger(true, ctxzap.Extract(ctx)))
+	apiURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain: %w", err)
+	}
+
+	client := FluidTopicsClient{
+		tokenSource: getTokenSource(bearerToken),
+		baseURL:     baseURL,
+		apiPath:     apiURL.Path,
+		metrics:     newRequestMetrics(metrics.NewNoOpHandler(ctx)),
+	}
+	for _, opt := range opts {
+		opt(&client)
+	}
+
+	httpOpts := []uhttp.Option{uhttp.WithLogger(true, ctxzap.Extract(ctx))}
+	if client.tlsConfig != nil {
+		httpOpts = append(httpOpts, uhttp.WithTLSClientConfig(client.tlsConfig))
+	}
+	httpClient, err := uhttp.NewClient(ctx, httpOpts...)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
@@ -47,12 +159,9 @@ func New(ctx context.Context, bearerToken string, domain string) (*FluidTopicsCl
 	if err != nil {
 		return nil, fmt.Errorf("failed to create base HTTP client: %w", err)
 	}
+	client.httpClient = cli
+	client.streamClient = httpClient
 
-	client := FluidTopicsClient{
-		httpClient:  cli,
-		tokenSource: getTokenSource(bearerToken),
-		baseURL:     baseURL,
-	}
 	return &client, nil
 }
 
@@ -72,6 +181,9 @@ func (c *FluidTopicsClient) ListUsers(ctx context.Context) ([]User, string, anno
 		l.Error(fmt.Sprintf("Error getting resources: %s", err))
 		return nil, "", nil, err
 	}
+	for i := range res {
+		res[i].scrubCredentials()
+	}
 
 	return res, "", annotation, nil
 }
@@ -81,7 +193,7 @@ func (c *FluidTopicsClient) GetUserDetails(ctx context.Context, userID string) (
 	var res UserDataResponse
 	var annotation annotations.Annotations
 
-	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserInfoById, userID))
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserInfoById, url.PathEscape(userID)))
 	if err != nil {
 		l.Error(fmt.Sprintf("Error creating URL: %s", err))
 		return res.User, nil, err
@@ -92,10 +204,76 @@ func (c *FluidTopicsClient) GetUserDetails(ctx context.Context, userID string) (
 		l.Error(fmt.Sprintf("Error getting resource: %s", err))
 		return res.User, nil, err
 	}
+	res.User.scrubCredentials()
 
 	return res.User, annotation, nil
 }
 
+// GetUserDump returns the full personal data export of the user, keyed by section.
+func (c *FluidTopicsClient) GetUserDump(ctx context.Context, userID string) (map[string]json.RawMessage, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res map[string]json.RawMessage
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserInfoById, url.PathEscape(userID)))
+	if err != nil {
+		l.Error(fmt.Sprintf("Error creating URL: %s", err))
+		return nil, nil, err
+	}
+
+	annotation, err := c.getResourcesFromAPI(ctx, queryUrl, &res)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resource: %s", err))
+		return nil, nil, err
+	}
+
+	return res, annotation, nil
+}
+
+// StreamUserDump returns the full personal data export of the user as it is received, the caller closes it. The
+// dump can be large, so unlike the other reads it is neither read at once nor cached.
+func (c *FluidTopicsClient) StreamUserDump(ctx context.Context, userID string) (io.ReadCloser, error) {
+	l := ctxzap.Extract(ctx)
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserInfoById, url.PathEscape(userID)))
+	if err != nil {
+		l.Error(fmt.Sprintf("Error creating URL: %s", err))
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	authToken, err := c.tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+	authToken.SetAuthHeader(req)
+
+	start := time.Now()
+	resp, err := c.streamClient.Do(req)
+	c.metrics.record(ctx, req.Method, endpointTemplate(c.endpointPath(req.URL)), resp, time.Since(start), nil)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resource: %s", err))
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		var errRes FluidTopicsAPIError
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&errRes); err == nil &&
+			(errRes.MessageStr != "" || errRes.ErrorText != "") {
+			return nil, errRes
+		}
+		return nil, fmt.Errorf("unexpected status getting data dump of user %s: %s", userID, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
 func (c *FluidTopicsClient) GetAuthenticationInfo(ctx context.Context) (AuthenticationInfo, annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 	var res AuthenticationInfo
@@ -121,7 +299,7 @@ func (c *FluidTopicsClient) GetRolesByUserID(ctx context.Context, userID string)
 	var user UserRoles
 	var annotation annotations.Annotations
 
-	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserRolesById, userID))
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserRolesById, url.PathEscape(userID)))
 	if err != nil {
 		l.Error(fmt.Sprintf("Error creating URL: %s", err))
 		return user, nil, err
@@ -139,7 +317,7 @@ func (c *FluidTopicsClient) GetRolesByUserID(ctx context.Context, userID string)
 func (c *FluidTopicsClient) UpdateUserManualRoles(ctx context.Context, userID string, manualRoles []string) (annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 
-	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserRolesById, userID))
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserRolesById, url.PathEscape(userID)))
 	if err != nil {
 		l.Error("error creating URL", zap.Error(err))
 		return nil, err
@@ -149,6 +327,10 @@ func (c *FluidTopicsClient) UpdateUserManualRoles(ctx context.Context, userID st
 		"manualRoles": manualRoles,
 	}
 
+	if c.audit != nil {
+		ctx = withAuditManualRoles(ctx, manualRoles)
+	}
+
 	_, annotation, err := c.doRequest(ctx, http.MethodPut, queryUrl, nil, body)
 	if err != nil {
 		return nil, err
@@ -175,6 +357,341 @@ func (c *FluidTopicsClient) CreateUser(ctx context.Context, newUser NewUserInfo)
 	return annotation, nil
 }
 
+func (c *FluidTopicsClient) ListRealms(ctx context.Context) ([]Realm, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res []Realm
+
+	queryUrl, err := url.JoinPath(c.baseURL, getRealms)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error creating URL: %s", err))
+		return nil, nil, err
+	}
+
+	annotation, err := c.getResourcesFromAPI(ctx, queryUrl, &res)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resources: %s", err))
+		return nil, nil, err
+	}
+
+	return res, annotation, nil
+}
+
+func (c *FluidTopicsClient) GetRealmMappingRules(ctx context.Context, realmID string) ([]RealmMappingRule, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res RealmMapping
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getRealmMappingRules, url.PathEscape(realmID)))
+	if err != nil {
+		l.Error(fmt.Sprintf("Error creating URL: %s", err))
+		return nil, nil, err
+	}
+
+	annotation, err := c.getResourcesFromAPI(ctx, queryUrl, &res)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resource: %s", err))
+		return nil, nil, err
+	}
+
+	return res.Rules, annotation, nil
+}
+
+// UpdateRealmMappingRules replaces the whole list of mapping rules of the realm.
+func (c *FluidTopicsClient) UpdateRealmMappingRules(ctx context.Context, realmID string, rules []RealmMappingRule) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getRealmMappingRules, url.PathEscape(realmID)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPut, queryUrl, nil, RealmMapping{Rules: rules})
+	if err != nil {
+		return nil, err
+	}
+
+	return annotation, nil
+}
+
+// ListAnalyticsEvents returns the analytics events that occurred since the start date of the request, oldest first.
+func (c *FluidTopicsClient) ListAnalyticsEvents(ctx context.Context, request AnalyticsEventsRequest) (AnalyticsEventsResponse, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res AnalyticsEventsResponse
+
+	queryUrl, err := url.JoinPath(c.baseURL, getAnalyticsEvents)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error creating URL: %s", err))
+		return res, nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPost, queryUrl, &res, request)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resources: %s", err))
+		return res, nil, err
+	}
+
+	return res, annotation, nil
+}
+
+// ListUserChanges returns the changes made to users by administrators since the start date of the request, oldest first.
+func (c *FluidTopicsClient) ListUserChanges(ctx context.Context, request UserChangesRequest) (UserChangesResponse, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res UserChangesResponse
+
+	queryUrl, err := url.JoinPath(c.baseURL, getUsersHistory)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error creating URL: %s", err))
+		return res, nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPost, queryUrl, &res, request)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resources: %s", err))
+		return res, nil, err
+	}
+
+	return res, annotation, nil
+}
+
+// GetUsersActivity returns, for all users at once, how many analytics events of each type they generated in the period.
+func (c *FluidTopicsClient) GetUsersActivity(ctx context.Context, request UsersActivityRequest) ([]UserActivityCount, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res UsersActivityResponse
+
+	queryUrl, err := url.JoinPath(c.baseURL, getUsersActivity)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error creating URL: %s", err))
+		return nil, nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPost, queryUrl, &res, request)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resources: %s", err))
+		return nil, nil, err
+	}
+
+	return res.Results, annotation, nil
+}
+
+// DeleteUser deletes the account of the user.
+func (c *FluidTopicsClient) DeleteUser(ctx context.Context, userID string) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(deleteUser, url.PathEscape(userID)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodDelete, queryUrl, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return annotation, nil
+}
+
+// DeleteUserContent deletes all the personal content of the given kind owned by the user.
+func (c *FluidTopicsClient) DeleteUserContent(ctx context.Context, userID string, kind string) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(deleteUserContent, url.PathEscape(userID), url.PathEscape(kind)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodDelete, queryUrl, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return annotation, nil
+}
+
+// AnonymizeUser detaches the identity of the user from its feedback, ratings and analytics events.
+func (c *FluidTopicsClient) AnonymizeUser(ctx context.Context, userID string) (UserAnonymization, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res UserAnonymization
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(anonymizeUser, url.PathEscape(userID)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return res, nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPost, queryUrl, &res, nil)
+	if err != nil {
+		return res, nil, err
+	}
+
+	return res, annotation, nil
+}
+
+// ListUserContent returns the personal content of the given kind owned by the user.
+func (c *FluidTopicsClient) ListUserContent(ctx context.Context, userID string, kind string) ([]UserContentItem, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res []UserContentItem
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserContent, url.PathEscape(userID), url.PathEscape(kind)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return nil, nil, err
+	}
+
+	annotation, err := c.getResourcesFromAPI(ctx, queryUrl, &res)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resource: %s", err))
+		return nil, nil, err
+	}
+
+	return res, annotation, nil
+}
+
+// TransferUserContent moves, or copies, an item of personal content of the user to another user.
+func (c *FluidTopicsClient) TransferUserContent(
+	ctx context.Context,
+	userID string,
+	kind string,
+	itemID string,
+	request UserContentTransferRequest,
+) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(transferUserContent, url.PathEscape(userID), url.PathEscape(kind), url.PathEscape(itemID)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPost, queryUrl, nil, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return annotation, nil
+}
+
+// UnshareUserContent stops sharing an item of personal content of the user with a user or a group.
+func (c *FluidTopicsClient) UnshareUserContent(ctx context.Context, userID string, kind string, itemID string, share ContentShare) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(deleteUserContentShare,
+		url.PathEscape(userID), url.PathEscape(kind), url.PathEscape(itemID), url.PathEscape(share.Type), url.PathEscape(share.Id)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodDelete, queryUrl, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return annotation, nil
+}
+
+// ListSavedSearches returns the saved searches of the user with their alert settings.
+func (c *FluidTopicsClient) ListSavedSearches(ctx context.Context, userID string) ([]SavedSearch, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res []SavedSearch
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getSavedSearches, url.PathEscape(userID)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return nil, nil, err
+	}
+
+	annotation, err := c.getResourcesFromAPI(ctx, queryUrl, &res)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resource: %s", err))
+		return nil, nil, err
+	}
+
+	return res, annotation, nil
+}
+
+// UpdateSavedSearchAlert sets how often the user is alerted of new results of the saved search.
+func (c *FluidTopicsClient) UpdateSavedSearchAlert(ctx context.Context, userID string, searchID string, frequency string) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(updateSavedSearchAlert, url.PathEscape(userID), url.PathEscape(searchID)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"frequency": frequency,
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPut, queryUrl, nil, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return annotation, nil
+}
+
+// RevokeUserSessions ends all the active sessions of the user and revokes its tokens.
+func (c *FluidTopicsClient) RevokeUserSessions(ctx context.Context, userID string) (SessionRevocation, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res SessionRevocation
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(revokeUserSessions, url.PathEscape(userID)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return res, nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPost, queryUrl, &res, nil)
+	if err != nil {
+		return res, nil, err
+	}
+
+	return res, annotation, nil
+}
+
+func (c *FluidTopicsClient) GetGroupsByUserID(ctx context.Context, userID string) (UserGroups, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var res UserGroups
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserGroupsById, url.PathEscape(userID)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return res, nil, err
+	}
+
+	annotation, err := c.getResourcesFromAPI(ctx, queryUrl, &res)
+	if err != nil {
+		l.Error(fmt.Sprintf("Error getting resource: %s", err))
+		return res, nil, err
+	}
+
+	return res, annotation, nil
+}
+
+func (c *FluidTopicsClient) UpdateUserManualGroups(ctx context.Context, userID string, manualGroups []string) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	queryUrl, err := url.JoinPath(c.baseURL, fmt.Sprintf(getUserGroupsById, url.PathEscape(userID)))
+	if err != nil {
+		l.Error("error creating URL", zap.Error(err))
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"manualGroups": manualGroups,
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPut, queryUrl, nil, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return annotation, nil
+}
+
 func (c *FluidTopicsClient) getResourcesFromAPI(
 	ctx context.Context,
 	urlAddress string,
@@ -209,6 +726,28 @@ func (c *FluidTopicsClient) doRequest(
 		o(urlAddress)
 	}
 
+	mutating := isMutatingRequest(method, c.endpointPath(urlAddress))
+	if c.readOnly && mutating {
+		return nil, nil, fmt.Errorf("read-only mode, refusing %s %s", method, urlAddress.Path)
+	}
+
+	if c.dryRun && mutating {
+		ctxzap.Extract(ctx).Info("dry run, request not sent",
+			zap.String("method", method),
+			zap.String("url", urlAddress.String()),
+			zap.String("body", redactedJSON(body)),
+		)
+		if c.audit != nil {
+			endpoint := endpointTemplate(c.endpointPath(urlAddress))
+			c.audit.record(ctx, method, urlAddress, endpoint, body, nil, AuditOutcomeDryRun, nil)
+		}
+		return nil, annotations.Annotations{}, nil
+	}
+
+	if method == http.MethodGet && slices.Contains(uncachedEndpoints, endpointTemplate(c.endpointPath(urlAddress))) {
+		clearHTTPCache(ctx)
+	}
+
 	authToken, err := c.tokenSource.Token()
 	if err != nil {
 		return nil, nil, err
@@ -239,7 +778,7 @@ func (c *FluidTopicsClient) doRequest(
 		if res != nil {
 			doOptions = append(doOptions, uhttp.WithResponse(res))
 		}
-		resp, err = c.httpClient.Do(req, doOptions...)
+		resp, err = c.do(ctx, req, body, doOptions, &rateLimitDesc)
 		if resp != nil {
 			defer resp.Body.Close()
 		}
@@ -250,10 +789,16 @@ func (c *FluidTopicsClient) doRequest(
 			return nil, nil, err
 		}
 	case http.MethodDelete:
-		resp, err = c.httpClient.Do(req)
+		resp, err = c.do(ctx, req, body, doOptions, &rateLimitDesc)
 		if resp != nil {
 			defer resp.Body.Close()
 		}
+		if err != nil {
+			if errRes.MessageStr != "" || errRes.ErrorText != "" {
+				return nil, nil, errRes
+			}
+			return nil, nil, err
+		}
 	}
 
 	annotation := annotations.Annotations{}
@@ -265,3 +810,55 @@ func (c *FluidTopicsClient) doRequest(
 
 	return nil, annotation, err
 }
+
+// do sends the request and measures it, per endpoint template. The requests changing Fluid Topics are recorded in
+// the audit log, when enabled.
+func (c *FluidTopicsClient) do(
+	ctx context.Context,
+	req *http.Request,
+	body interface{},
+	doOptions []uhttp.DoOption,
+	rateLimitDesc *v2.RateLimitDescription,
+) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req, doOptions...)
+
+	endpoint := endpointTemplate(c.endpointPath(req.URL))
+	c.metrics.record(ctx, req.Method, endpoint, resp, time.Since(start), rateLimitDesc)
+
+	if isMutatingRequest(req.Method, c.endpointPath(req.URL)) {
+		// The cached reads may no longer match what Fluid Topics holds.
+		clearHTTPCache(ctx)
+
+		if c.audit != nil {
+			outcome := AuditOutcomeSuccess
+			if err != nil {
+				outcome = AuditOutcomeFailure
+			}
+			c.audit.record(ctx, req.Method, req.URL, endpoint, body, resp, outcome, err)
+		}
+	}
+
+	return resp, err
+}
+
+// endpointPath returns the path of the URL relative to the API, escaped so an ID holding a slash stays one segment.
+func (c *FluidTopicsClient) endpointPath(u *url.URL) string {
+	return strings.TrimPrefix(u.EscapedPath(), c.apiPath)
+}
+
+// clearHTTPCache drops the GET responses cached by the SDK, the next reads are sent to Fluid Topics.
+func clearHTTPCache(ctx context.Context) {
+	if err := uhttp.ClearCaches(ctx); err != nil {
+		ctxzap.Extract(ctx).Warn("error clearing the HTTP cache", zap.Error(err))
+	}
+}
+
+// isMutatingRequest reports whether the request can change anything in Fluid Topics, path being relative to the API.
+func isMutatingRequest(method string, path string) bool {
+	if method == http.MethodGet {
+		return false
+	}
+
+	return !slices.Contains(readOnlyEndpoints, path)
+}
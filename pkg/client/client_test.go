@@ -0,0 +1,252 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestIsMutatingRequest(t *testing.T) {
+	testCases := []struct {
+		method   string
+		endpoint string
+		mutating bool
+	}{
+		{http.MethodGet, "/users/user-1/roles", false},
+		{http.MethodPut, "/users/user-1/roles", true},
+		{http.MethodPost, getUsersHistory, false},
+		{http.MethodPost, getAnalyticsEvents, false},
+		{http.MethodPost, createUser, true},
+		{http.MethodDelete, "/users/user-1", true},
+		// Only the exact read-only endpoints are allowed.
+		{http.MethodPost, "/users/user-1" + getUsersHistory, true},
+		{http.MethodDelete, "/users/user-1" + getAnalyticsEvents, true},
+		{http.MethodPost, getUsersHistory + "/", true},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.mutating, isMutatingRequest(tc.method, tc.endpoint), "%s %s", tc.method, tc.endpoint)
+	}
+}
+
+func TestRedactedJSON(t *testing.T) {
+	body := NewUserInfo{Name: "Jane", EmailAddress: "jane@x.com", Password: "s3cret"}
+
+	redacted := redactedJSON(body)
+	require.NotContains(t, redacted, "s3cret")
+	require.Contains(t, redacted, RedactedValue)
+	require.Contains(t, redacted, "jane@x.com")
+
+	// Keys containing a credential word are redacted too, at any depth.
+	redacted = redactedJSON(map[string]interface{}{
+		"name":         "integration",
+		"accessToken":  "s3cret-access",
+		"refreshToken": "s3cret-refresh",
+		"apiKeys":      []string{"s3cret-key"},
+		"settings": []interface{}{
+			map[string]interface{}{"clientSecret": "s3cret-client", "client_id": "portal"},
+		},
+	})
+	require.JSONEq(t, `{
+		"name": "integration",
+		"accessToken": "[REDACTED]",
+		"refreshToken": "[REDACTED]",
+		"apiKeys": "[REDACTED]",
+		"settings": [{"clientSecret": "[REDACTED]", "client_id": "portal"}]
+	}`, redacted)
+}
+
+func TestIsCredentialKey(t *testing.T) {
+	for _, key := range []string{"password", "Password", "token", "accessToken", "refresh_token", "apiKey", "apiKeys", "api-key", "clientSecret", "secrets"} {
+		require.True(t, IsCredentialKey(key), key)
+	}
+	for _, key := range []string{"login", "emailAddress", "id", "keywords"} {
+		require.False(t, IsCredentialKey(key), key)
+	}
+}
+
+func TestStreamUserDump(t *testing.T) {
+	ctx := context.Background()
+	server, tlsOpt := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/users/user-1/dump":
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"user":{"id":"user-1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":404,"error":"Not Found","message":"unknown user"}`))
+		}
+	}))
+
+	c, err := New(ctx, "token", server.URL, tlsOpt)
+	require.NoError(t, err)
+
+	dump, err := c.StreamUserDump(ctx, "user-1")
+	require.NoError(t, err)
+	data, err := io.ReadAll(dump)
+	require.NoError(t, err)
+	require.NoError(t, dump.Close())
+	require.JSONEq(t, `{"user":{"id":"user-1"}}`, string(data))
+
+	_, err = c.StreamUserDump(ctx, "ghost")
+	require.ErrorContains(t, err, "unknown user")
+}
+
+func TestPathSegmentsAreEscaped(t *testing.T) {
+	ctx := context.Background()
+	var paths []string
+	server, tlsOpt := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		_, _ = w.Write([]byte(`{}`))
+	}))
+
+	var audit bytes.Buffer
+	c, err := New(ctx, "token", server.URL, tlsOpt, WithAuditLog(newAuditLog(&audit)))
+	require.NoError(t, err)
+
+	_, err = c.DeleteUser(ctx, "team/user?1")
+	require.NoError(t, err)
+	_, err = c.UnshareUserContent(ctx, "team/user?1", UserContentPersonalBooks, "book#1", ContentShare{Type: ContentShareGroup, Id: "a/b"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"DELETE /api/users/team%2Fuser%3F1",
+		"DELETE /api/users/team%2Fuser%3F1/personal-books/book%231/shares/group/a%2Fb",
+	}, paths)
+
+	// The escaped IDs stay one segment of the endpoint.
+	entries := readAuditEntries(t, audit.Bytes())
+	require.Len(t, entries, 2)
+	require.Equal(t, deleteUser, entries[0].Endpoint)
+	require.Equal(t, "team/user?1", entries[0].TargetUser)
+	require.Equal(t, deleteUserContentShare, entries[1].Endpoint)
+	require.Equal(t, "team/user?1", entries[1].TargetUser)
+}
+
+func TestNewUserInfoLoggingSafe(t *testing.T) {
+	newUser := NewUserInfo{Name: "Jane", EmailAddress: "jane@x.com", Password: "s3cret"}
+	credentials := Credentials{Login: "jane@x.com", Password: "s3cret"}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		require.NotContains(t, fmt.Sprintf(format, newUser), "s3cret", format)
+		require.NotContains(t, fmt.Sprintf(format, credentials), "s3cret", format)
+		require.NotContains(t, fmt.Sprintf(format, User{Id: "user-1", Credentials: credentials}), "s3cret", format)
+	}
+
+	var logs bytes.Buffer
+	logger := newBufferLogger(&logs)
+	logger.Info("registering", zap.Object("user", newUser), zap.Any("body", newUser), zap.Object("credentials", credentials))
+	require.NotContains(t, logs.String(), "s3cret")
+	require.Contains(t, logs.String(), "jane@x.com")
+
+	// The registration body sent to Fluid Topics keeps the password.
+	data, err := json.Marshal(newUser)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "s3cret")
+}
+
+func TestScrubCredentials(t *testing.T) {
+	user := User{Id: "user-1", Credentials: Credentials{Login: "jane@x.com", Password: "s3cret"}}
+	user.scrubCredentials()
+
+	data, err := json.Marshal(user)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "password")
+	require.Equal(t, "jane@x.com", user.Credentials.Login)
+}
+
+func TestNoSecretsInRequestLogs(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := ctxzap.ToContext(context.Background(), newBufferLogger(&logs))
+
+	authorized := 0
+	server, tlsOpt := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer bearer-s3cret-token" {
+			authorized++
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/users/register":
+			// The registration is rejected, the error path logs the response.
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"Conflict","message":"user already exists","status":409}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/users/user-1/dump":
+			_, _ = w.Write([]byte(`{"user":{"id":"user-1","emailAddress":"jane@x.com","credentials":{"login":"jane@x.com","password":"s3cret"}}}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	c, err := New(ctx, "bearer-s3cret-token", server.URL, tlsOpt)
+	require.NoError(t, err)
+
+	_, err = c.CreateUser(ctx, NewUserInfo{Name: "Jane", EmailAddress: "jane@x.com", Password: "s3cret"})
+	require.Error(t, err)
+	user, _, err := c.GetUserDetails(ctx, "user-1")
+	require.NoError(t, err)
+	require.Empty(t, user.Credentials.Password)
+	_, err = c.UpdateUserManualRoles(ctx, "user-1", []string{"ADMIN"})
+	require.NoError(t, err)
+
+	// Every request went through the bearer token and the logging transport.
+	require.Equal(t, 3, authorized)
+	require.Contains(t, logs.String(), "/api/users/register")
+	require.NotContains(t, logs.String(), "s3cret")
+	require.NotContains(t, logs.String(), "bearer-s3cret-token")
+}
+
+func TestNoSecretsInDryRunLogs(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := ctxzap.ToContext(context.Background(), newBufferLogger(&logs))
+
+	c, err := New(ctx, "bearer-s3cret-token", "https://example.fluidtopics.net", WithDryRun(true))
+	require.NoError(t, err)
+
+	_, err = c.CreateUser(ctx, NewUserInfo{Name: "Jane", EmailAddress: "jane@x.com", Password: "s3cret"})
+	require.NoError(t, err)
+
+	require.Contains(t, logs.String(), "jane@x.com")
+	require.NotContains(t, logs.String(), "s3cret")
+	require.NotContains(t, logs.String(), "bearer-s3cret-token")
+}
+
+// newTestServer starts a Fluid Topics API serving the handler, the option makes the client trust its certificate.
+func newTestServer(t *testing.T, handler http.Handler) (*httptest.Server, Option) {
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	transport, ok := server.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+
+	return server, WithTLSClientConfig(transport.TLSClientConfig)
+}
+
+func newBufferLogger(w *bytes.Buffer) *zap.Logger {
+	return zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(w), zap.DebugLevel))
+}
+
+func TestReadOnlyRefusesMutatingRequests(t *testing.T) {
+	ctx := context.Background()
+
+	// The dry run does not send the requests, the read-only mode refuses them nonetheless.
+	c, err := New(ctx, "token", "https://example.fluidtopics.net", WithReadOnly(true), WithDryRun(true))
+	require.NoError(t, err)
+
+	_, err = c.UpdateUserManualRoles(ctx, "user-1", []string{"ADMIN"})
+	require.ErrorContains(t, err, "read-only")
+	_, err = c.CreateUser(ctx, NewUserInfo{Name: "Jane", EmailAddress: "jane@x.com"})
+	require.ErrorContains(t, err, "read-only")
+	_, err = c.DeleteUser(ctx, "user-1")
+	require.ErrorContains(t, err, "read-only")
+}
@@ -2,6 +2,8 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 )
@@ -9,8 +11,27 @@ import (
 type FluidTopicsClientInterface interface {
 	ListUsers(ctx context.Context) ([]User, string, annotations.Annotations, error)
 	GetUserDetails(ctx context.Context, userID string) (User, annotations.Annotations, error)
+	GetUserDump(ctx context.Context, userID string) (map[string]json.RawMessage, annotations.Annotations, error)
+	StreamUserDump(ctx context.Context, userID string) (io.ReadCloser, error)
 	GetAuthenticationInfo(ctx context.Context) (AuthenticationInfo, annotations.Annotations, error)
 	UpdateUserManualRoles(ctx context.Context, userID string, manualRoles []string) (annotations.Annotations, error)
 	CreateUser(ctx context.Context, newUser NewUserInfo) (annotations.Annotations, error)
 	GetRolesByUserID(ctx context.Context, userID string) (UserRoles, annotations.Annotations, error)
+	GetGroupsByUserID(ctx context.Context, userID string) (UserGroups, annotations.Annotations, error)
+	UpdateUserManualGroups(ctx context.Context, userID string, manualGroups []string) (annotations.Annotations, error)
+	ListRealms(ctx context.Context) ([]Realm, annotations.Annotations, error)
+	GetRealmMappingRules(ctx context.Context, realmID string) ([]RealmMappingRule, annotations.Annotations, error)
+	UpdateRealmMappingRules(ctx context.Context, realmID string, rules []RealmMappingRule) (annotations.Annotations, error)
+	ListAnalyticsEvents(ctx context.Context, request AnalyticsEventsRequest) (AnalyticsEventsResponse, annotations.Annotations, error)
+	ListUserChanges(ctx context.Context, request UserChangesRequest) (UserChangesResponse, annotations.Annotations, error)
+	GetUsersActivity(ctx context.Context, request UsersActivityRequest) ([]UserActivityCount, annotations.Annotations, error)
+	DeleteUser(ctx context.Context, userID string) (annotations.Annotations, error)
+	DeleteUserContent(ctx context.Context, userID string, kind string) (annotations.Annotations, error)
+	RevokeUserSessions(ctx context.Context, userID string) (SessionRevocation, annotations.Annotations, error)
+	AnonymizeUser(ctx context.Context, userID string) (UserAnonymization, annotations.Annotations, error)
+	ListUserContent(ctx context.Context, userID string, kind string) ([]UserContentItem, annotations.Annotations, error)
+	UnshareUserContent(ctx context.Context, userID string, kind string, itemID string, share ContentShare) (annotations.Annotations, error)
+	ListSavedSearches(ctx context.Context, userID string) ([]SavedSearch, annotations.Annotations, error)
+	UpdateSavedSearchAlert(ctx context.Context, userID string, searchID string, frequency string) (annotations.Annotations, error)
+	TransferUserContent(ctx context.Context, userID string, kind string, itemID string, request UserContentTransferRequest) (annotations.Annotations, error)
 }
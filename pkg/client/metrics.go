@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/metrics"
+)
+
+// endpoints are the templates of the Fluid Topics API paths, the requests are measured per template rather than per
+// path so the user and content IDs do not end up in the metric tags.
+var endpoints = []string{
+	getUsers,
+	getUserRolesById,
+	getUserInfoById,
+	getAuthenticationInfo,
+	createUser,
+	getRealms,
+	getRealmMappingRules,
+	getAnalyticsEvents,
+	getUsersHistory,
+	getUsersActivity,
+	deleteUser,
+	deleteUserContent,
+	anonymizeUser,
+	transferUserContent,
+	deleteUserContentShare,
+	getSavedSearches,
+	updateSavedSearchAlert,
+	revokeUserSessions,
+	getUserGroupsById,
+}
+
+// unknownEndpoint tags the requests to a path matching none of the endpoints.
+const unknownEndpoint = "unknown"
+
+// requestMetrics measures the requests made to Fluid Topics.
+type requestMetrics struct {
+	requests           metrics.Int64Counter
+	responses          metrics.Int64Counter
+	latency            metrics.Int64Histogram
+	retryableResponses metrics.Int64Counter
+	rateLimitRemaining metrics.Int64Gauge
+}
+
+func newRequestMetrics(h metrics.Handler) *requestMetrics {
+	return &requestMetrics{
+		requests: h.Int64Counter(
+			"fluid_topics_api_requests",
+			"Number of requests made to the Fluid Topics API",
+			metrics.Dimensionless,
+		),
+		responses: h.Int64Counter(
+			"fluid_topics_api_responses",
+			"Number of responses from the Fluid Topics API, by status code",
+			metrics.Dimensionless,
+		),
+		latency: h.Int64Histogram(
+			"fluid_topics_api_request_duration",
+			"Duration of the requests made to the Fluid Topics API",
+			metrics.Milliseconds,
+		),
+		retryableResponses: h.Int64Counter(
+			"fluid_topics_api_retryable_responses",
+			"Number of responses from the Fluid Topics API with a status the SDK may retry the request on",
+			metrics.Dimensionless,
+		),
+		rateLimitRemaining: h.Int64Gauge(
+			"fluid_topics_api_rate_limit_remaining",
+			"Number of requests left in the Fluid Topics API rate limit window",
+			metrics.Dimensionless,
+		),
+	}
+}
+
+// record measures a request sent to Fluid Topics. resp is nil when no response was received.
+func (m *requestMetrics) record(
+	ctx context.Context,
+	method string,
+	endpoint string,
+	resp *http.Response,
+	duration time.Duration,
+	rateLimit *v2.RateLimitDescription,
+) {
+	tags := map[string]string{
+		"endpoint": endpoint,
+		"method":   method,
+	}
+	m.requests.Add(ctx, 1, tags)
+	m.latency.Record(ctx, duration.Milliseconds(), tags)
+
+	statusCode := "none"
+	if resp != nil {
+		statusCode = strconv.Itoa(resp.StatusCode)
+		if isRetryableStatus(resp.StatusCode) {
+			m.retryableResponses.Add(ctx, 1, tags)
+		}
+	}
+	m.responses.Add(ctx, 1, map[string]string{
+		"endpoint":    endpoint,
+		"method":      method,
+		"status_code": statusCode,
+	})
+
+	// Fluid Topics only sends the rate limit headers on the rate limited endpoints.
+	if rateLimit != nil && rateLimit.Limit > 0 {
+		m.rateLimitRemaining.Observe(ctx, rateLimit.Remaining, map[string]string{"endpoint": endpoint})
+	}
+}
+
+// isRetryableStatus reports whether the SDK may retry the request after this status: uhttp turns them into Unavailable
+// or DeadlineExceeded errors.
+func isRetryableStatus(statusCode int) bool {
+	switch {
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests:
+		return true
+	case statusCode == http.StatusNotImplemented:
+		return false
+	default:
+		return statusCode >= 500 && statusCode <= 599
+	}
+}
+
+// endpointTemplate returns the endpoint the API path, relative to the API base path, was built from.
+// When several endpoints match, the one with the most literal segments wins, e.g. /users/%s/roles over /users/%s/%s.
+func endpointTemplate(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	best, bestLiterals := unknownEndpoint, -1
+	for _, endpoint := range endpoints {
+		endpointSegments := strings.Split(strings.Trim(endpoint, "/"), "/")
+		if len(endpointSegments) != len(segments) {
+			continue
+		}
+
+		literals := 0
+		matches := true
+		for i, segment := range endpointSegments {
+			if segment == "%s" {
+				continue
+			}
+			if segment != segments[i] {
+				matches = false
+				break
+			}
+			literals++
+		}
+
+		if matches && literals > bestLiterals {
+			best, bestLiterals = endpoint, literals
+		}
+	}
+
+	return best
+}
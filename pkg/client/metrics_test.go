@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/metrics"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// recordingHandler keeps the sum of the values counted and recorded, and the last value observed, per metric and tags.
+type recordingHandler struct {
+	values map[string]int64
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{values: make(map[string]int64)}
+}
+
+type recorder struct {
+	h    *recordingHandler
+	name string
+}
+
+func (r *recorder) key(tags map[string]string) string {
+	return fmt.Sprintf("%s%v", r.name, tags)
+}
+
+func (r *recorder) Add(_ context.Context, value int64, tags map[string]string) {
+	r.h.values[r.key(tags)] += value
+}
+
+func (r *recorder) Record(_ context.Context, value int64, tags map[string]string) {
+	r.h.values[r.key(tags)] += value
+}
+
+func (r *recorder) Observe(_ context.Context, value int64, tags map[string]string) {
+	r.h.values[r.key(tags)] = value
+}
+
+func (h *recordingHandler) Int64Counter(name string, _ string, _ metrics.Unit) metrics.Int64Counter {
+	return &recorder{h: h, name: name}
+}
+
+func (h *recordingHandler) Int64Gauge(name string, _ string, _ metrics.Unit) metrics.Int64Gauge {
+	return &recorder{h: h, name: name}
+}
+
+func (h *recordingHandler) Int64Histogram(name string, _ string, _ metrics.Unit) metrics.Int64Histogram {
+	return &recorder{h: h, name: name}
+}
+
+func (h *recordingHandler) WithTags(_ map[string]string) metrics.Handler {
+	return h
+}
+
+func TestEndpointTemplate(t *testing.T) {
+	testCases := map[string]string{
+		"/users":                                      getUsers,
+		"/users/user-1":                               deleteUser,
+		"/users/user-1/roles":                         getUserRolesById,
+		"/users/user-1/dump":                          getUserInfoById,
+		"/users/user-1/bookmarks":                     getUserContent,
+		"/users/user-1/saved-searches":                getSavedSearches,
+		"/users/user-1/saved-searches/search-1/alert": updateSavedSearchAlert,
+		"/users/user-1/collections/c-1/transfer":      transferUserContent,
+		"/admin/users/history":                        getUsersHistory,
+		"/admin/realms/okta/mapping-rules":            getRealmMappingRules,
+		"/analytics/v1/events":                        getAnalyticsEvents,
+		"/unknown/endpoint":                           unknownEndpoint,
+	}
+
+	for path, expected := range testCases {
+		require.Equal(t, expected, endpointTemplate(path), path)
+	}
+}
+
+func TestRequestMetricsRecord(t *testing.T) {
+	ctx := context.Background()
+	h := newRecordingHandler()
+	m := newRequestMetrics(h)
+
+	m.record(ctx, http.MethodGet, getUserRolesById, &http.Response{StatusCode: http.StatusTooManyRequests}, 30*time.Millisecond,
+		&v2.RateLimitDescription{Limit: 100, Remaining: 0})
+	m.record(ctx, http.MethodGet, getUserRolesById, &http.Response{StatusCode: http.StatusOK}, 120*time.Millisecond,
+		&v2.RateLimitDescription{Limit: 100, Remaining: 42})
+	m.record(ctx, http.MethodPut, getUserRolesById, nil, 10*time.Millisecond, &v2.RateLimitDescription{})
+
+	get := map[string]string{"endpoint": getUserRolesById, "method": http.MethodGet}
+	require.Equal(t, int64(2), h.values[fmt.Sprintf("fluid_topics_api_requests%v", get)])
+	require.Equal(t, int64(150), h.values[fmt.Sprintf("fluid_topics_api_request_duration%v", get)])
+	require.Equal(t, int64(1), h.values[fmt.Sprintf("fluid_topics_api_retryable_responses%v", get)])
+	require.Equal(t, int64(1), h.values[fmt.Sprintf("fluid_topics_api_responses%v",
+		map[string]string{"endpoint": getUserRolesById, "method": http.MethodGet, "status_code": "429"})])
+	require.Equal(t, int64(1), h.values[fmt.Sprintf("fluid_topics_api_responses%v",
+		map[string]string{"endpoint": getUserRolesById, "method": http.MethodPut, "status_code": "none"})])
+	// The request without rate limit headers leaves the gauge untouched.
+	require.Equal(t, int64(42), h.values[fmt.Sprintf("fluid_topics_api_rate_limit_remaining%v", map[string]string{"endpoint": getUserRolesById})])
+}
+
+// TestRequestMetricsReachOtelReader checks that the request metrics reach the readers of the OTel meter provider given
+// to the OTel handler of the SDK.
+func TestRequestMetricsReachOtelReader(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	server, tlsOpt := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"user-1","manualRoles":["ADMIN"]}`))
+	}))
+
+	c, err := New(ctx, "token", server.URL, tlsOpt, WithMetricsHandler(metrics.NewOtelHandler(ctx, provider, "baton-fluid-topics")))
+	require.NoError(t, err)
+	_, _, err = c.GetRolesByUserID(ctx, "user-1")
+	require.NoError(t, err)
+
+	var collected metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &collected))
+
+	var names []string
+	for _, scope := range collected.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			names = append(names, m.Name)
+		}
+	}
+	require.Contains(t, names, "fluid_topics_api_requests")
+	require.Contains(t, names, "fluid_topics_api_responses")
+}
@@ -2,6 +2,8 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/stretchr/testify/mock"
@@ -40,3 +42,105 @@ func (m *MockFluidTopicsClient) CreateUser(ctx context.Context, newUser NewUserI
 	args := m.Called(ctx, newUser)
 	return args.Get(0).(annotations.Annotations), args.Error(1)
 }
+
+func (m *MockFluidTopicsClient) ListRealms(ctx context.Context) ([]Realm, annotations.Annotations, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Realm), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) GetRealmMappingRules(ctx context.Context, realmID string) ([]RealmMappingRule, annotations.Annotations, error) {
+	args := m.Called(ctx, realmID)
+	return args.Get(0).([]RealmMappingRule), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) UpdateRealmMappingRules(ctx context.Context, realmID string, rules []RealmMappingRule) (annotations.Annotations, error) {
+	args := m.Called(ctx, realmID, rules)
+	return args.Get(0).(annotations.Annotations), args.Error(1)
+}
+
+func (m *MockFluidTopicsClient) ListAnalyticsEvents(ctx context.Context, request AnalyticsEventsRequest) (AnalyticsEventsResponse, annotations.Annotations, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(AnalyticsEventsResponse), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) ListUserChanges(ctx context.Context, request UserChangesRequest) (UserChangesResponse, annotations.Annotations, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(UserChangesResponse), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) GetUsersActivity(ctx context.Context, request UsersActivityRequest) ([]UserActivityCount, annotations.Annotations, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).([]UserActivityCount), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) GetUserDump(ctx context.Context, userID string) (map[string]json.RawMessage, annotations.Annotations, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(map[string]json.RawMessage), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) StreamUserDump(ctx context.Context, userID string) (io.ReadCloser, error) {
+	args := m.Called(ctx, userID)
+	dump, _ := args.Get(0).(io.ReadCloser)
+	return dump, args.Error(1)
+}
+
+func (m *MockFluidTopicsClient) DeleteUser(ctx context.Context, userID string) (annotations.Annotations, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(annotations.Annotations), args.Error(1)
+}
+
+func (m *MockFluidTopicsClient) DeleteUserContent(ctx context.Context, userID string, kind string) (annotations.Annotations, error) {
+	args := m.Called(ctx, userID, kind)
+	return args.Get(0).(annotations.Annotations), args.Error(1)
+}
+
+func (m *MockFluidTopicsClient) AnonymizeUser(ctx context.Context, userID string) (UserAnonymization, annotations.Annotations, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(UserAnonymization), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) ListUserContent(ctx context.Context, userID string, kind string) ([]UserContentItem, annotations.Annotations, error) {
+	args := m.Called(ctx, userID, kind)
+	return args.Get(0).([]UserContentItem), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) TransferUserContent(
+	ctx context.Context,
+	userID string,
+	kind string,
+	itemID string,
+	request UserContentTransferRequest,
+) (annotations.Annotations, error) {
+	args := m.Called(ctx, userID, kind, itemID, request)
+	return args.Get(0).(annotations.Annotations), args.Error(1)
+}
+
+func (m *MockFluidTopicsClient) UnshareUserContent(ctx context.Context, userID string, kind string, itemID string, share ContentShare) (annotations.Annotations, error) {
+	args := m.Called(ctx, userID, kind, itemID, share)
+	return args.Get(0).(annotations.Annotations), args.Error(1)
+}
+
+func (m *MockFluidTopicsClient) ListSavedSearches(ctx context.Context, userID string) ([]SavedSearch, annotations.Annotations, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]SavedSearch), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) UpdateSavedSearchAlert(ctx context.Context, userID string, searchID string, frequency string) (annotations.Annotations, error) {
+	args := m.Called(ctx, userID, searchID, frequency)
+	return args.Get(0).(annotations.Annotations), args.Error(1)
+}
+
+func (m *MockFluidTopicsClient) RevokeUserSessions(ctx context.Context, userID string) (SessionRevocation, annotations.Annotations, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(SessionRevocation), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) GetGroupsByUserID(ctx context.Context, userID string) (UserGroups, annotations.Annotations, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(UserGroups), args.Get(1).(annotations.Annotations), args.Error(2)
+}
+
+func (m *MockFluidTopicsClient) UpdateUserManualGroups(ctx context.Context, userID string, manualGroups []string) (annotations.Annotations, error) {
+	args := m.Called(ctx, userID, manualGroups)
+	return args.Get(0).(annotations.Annotations), args.Error(1)
+}
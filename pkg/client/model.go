@@ -8,6 +8,7 @@ type User struct {
 	Email                     string                      `json:"emailAddress"`
 	CreationDate              time.Time                   `json:"creationDate"`
 	LastLoginDate             time.Time                   `json:"lastActivityDate"`
+	ModificationDate          time.Time                   `json:"modificationDate"`
 	AuthenticationIdentifiers []AuthenticationIdentifiers `json:"authenticationIdentifiers"`
 	Credentials               Credentials                 `json:"credentials"`
 }
@@ -23,9 +24,10 @@ type AuthenticationInfo struct {
 	} `json:"profile"`
 }
 
+// Credentials are decoded from the user dumps, the password is scrubbed right after decoding.
 type Credentials struct {
 	Login    string `json:"login"`
-	Password string `json:"password"`
+	Password string `json:"password,omitempty"`
 }
 
 type UserRoles struct {
@@ -35,6 +37,12 @@ type UserRoles struct {
 	DefaultRoles        []string `json:"defaultRoles"`
 }
 
+type UserGroups struct {
+	Id                   string   `json:"id"`
+	ManualGroups         []string `json:"manualGroups"`
+	AuthenticationGroups []string `json:"authenticationGroups"`
+}
+
 type FluidTopicsAPIError struct {
 	Timestamp  string `json:"timestamp"`
 	Status     int    `json:"status"`
@@ -43,6 +51,8 @@ type FluidTopicsAPIError struct {
 	Path       string `json:"path"`
 }
 
+// NewUserInfo is the registration body of a user. It holds the password, so its String and zap representations
+// redact it: log the value itself, never its fields.
 type NewUserInfo struct {
 	Name                   string `json:"name"`
 	EmailAddress           string `json:"emailAddress"`
@@ -59,3 +69,169 @@ type Role struct {
 	Description string
 	Type        string
 }
+
+type Realm struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type RealmMapping struct {
+	Rules []RealmMappingRule `json:"rules"`
+}
+
+// RealmMappingRule confers roles and groups to the users of a realm whose identity provider
+// attribute has the given value.
+type RealmMappingRule struct {
+	Attribute string   `json:"attribute"`
+	Value     string   `json:"value"`
+	Roles     []string `json:"roles"`
+	Groups    []string `json:"groups"`
+}
+
+const (
+	AnalyticsEventLogin           = "user.login"
+	AnalyticsEventDocumentDisplay = "document.display"
+	AnalyticsEventSearch          = "search.perform"
+	AnalyticsEventDocumentExport  = "document.export"
+	AnalyticsEventGenerativeAI    = "ai.query"
+)
+
+type AnalyticsEventsRequest struct {
+	StartDate  time.Time `json:"startDate"`
+	EventTypes []string  `json:"eventTypes,omitempty"`
+	Limit      int       `json:"limit"`
+}
+
+type AnalyticsEventsResponse struct {
+	Events  []AnalyticsEvent `json:"events"`
+	HasMore bool             `json:"hasMore"`
+}
+
+type AnalyticsEvent struct {
+	Id       string    `json:"id"`
+	Type     string    `json:"type"`
+	Date     time.Time `json:"date"`
+	UserId   string    `json:"userId"`
+	UserName string    `json:"userName"`
+	Document struct {
+		Id    string `json:"id"`
+		Title string `json:"title"`
+	} `json:"document"`
+	Query  string `json:"query"`
+	Format string `json:"format"`
+}
+
+const (
+	UserChangeManualRoles = "manualRoles"
+	UserChangeGroups      = "groups"
+)
+
+type UserChangesRequest struct {
+	StartDate time.Time `json:"startDate"`
+	Limit     int       `json:"limit"`
+}
+
+type UserChangesResponse struct {
+	Changes []UserChange `json:"changes"`
+	HasMore bool         `json:"hasMore"`
+}
+
+// UserChange is an entry of the users history, it records the values added to and removed from a field of a user.
+type UserChange struct {
+	Id       string    `json:"id"`
+	Date     time.Time `json:"date"`
+	UserId   string    `json:"userId"`
+	AuthorId string    `json:"authorId"`
+	Field    string    `json:"field"`
+	Added    []string  `json:"added"`
+	Removed  []string  `json:"removed"`
+}
+
+type UsersActivityRequest struct {
+	StartDate  time.Time `json:"startDate"`
+	EndDate    time.Time `json:"endDate"`
+	EventTypes []string  `json:"eventTypes"`
+}
+
+type UsersActivityResponse struct {
+	Results []UserActivityCount `json:"results"`
+}
+
+// UserActivityCount is the number of events of a type generated by a user, exports are also split by format.
+type UserActivityCount struct {
+	UserId string `json:"userId"`
+	Type   string `json:"type"`
+	Format string `json:"format"`
+	Count  int    `json:"count"`
+}
+
+// Kinds of personal content owned by a user, they are the sections of the user data dump and the paths of the
+// personal content endpoints.
+const (
+	UserContentPersonalBooks = "personal-books"
+	UserContentCollections   = "collections"
+	UserContentBookmarks     = "bookmarks"
+	UserContentSavedSearches = "saved-searches"
+)
+
+// UserDumpSections maps every kind of personal content to its section in the user data dump.
+var UserDumpSections = map[string]string{
+	UserContentPersonalBooks: "personalBooks",
+	UserContentCollections:   "collections",
+	UserContentBookmarks:     "bookmarks",
+	UserContentSavedSearches: "savedSearches",
+}
+
+// UserAnonymization reports how many items were detached from the identity of a user.
+type UserAnonymization struct {
+	Feedback        int `json:"feedback"`
+	Ratings         int `json:"ratings"`
+	AnalyticsEvents int `json:"analyticsEvents"`
+}
+
+// UserContentItem is an item of personal content, such as a personal book or a saved search.
+type UserContentItem struct {
+	Id     string         `json:"id"`
+	Title  string         `json:"title"`
+	Shares []ContentShare `json:"shares"`
+}
+
+const (
+	ContentShareUser  = "user"
+	ContentShareGroup = "group"
+)
+
+// ContentShare is a user or a group a personal book or collection is shared with.
+type ContentShare struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+const (
+	UserContentTransferMove = "move"
+	UserContentTransferCopy = "copy"
+)
+
+type UserContentTransferRequest struct {
+	TargetUserId string `json:"targetUserId"`
+	Mode         string `json:"mode"`
+}
+
+// SavedSearchAlertNone is the alert frequency of a saved search without email alerts.
+const SavedSearchAlertNone = "none"
+
+// SavedSearch is a search saved by a user, it can send email alerts about its new results.
+type SavedSearch struct {
+	Id             string `json:"id"`
+	Title          string `json:"title"`
+	Query          string `json:"query"`
+	AlertFrequency string `json:"alertFrequency"`
+}
+
+// SessionRevocation reports how many sessions and tokens of a user were ended.
+type SessionRevocation struct {
+	Sessions int `json:"sessions"`
+	Tokens   int `json:"tokens"`
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue replaces the value of credentials in everything the connector logs or returns.
+const RedactedValue = "[REDACTED]"
+
+// Words whose keys hold credentials, such as accessToken or client_secret, they are redacted wherever they appear.
+var credentialKeys = []string{"password", "token", "apikey", "secret"}
+
+// keySeparators are dropped from the keys before looking for the credential words, so api_key matches apikey.
+var keySeparators = strings.NewReplacer("_", "", "-", "")
+
+// IsCredentialKey reports whether the values of the key are credentials: the key contains one of the credential
+// words, ignoring case and separators.
+func IsCredentialKey(key string) bool {
+	key = keySeparators.Replace(strings.ToLower(key))
+	return slices.ContainsFunc(credentialKeys, func(word string) bool {
+		return strings.Contains(key, word)
+	})
+}
+
+// RedactCredentials replaces the value of every credential key of the decoded JSON value, at any depth.
+func RedactCredentials(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if IsCredentialKey(key) {
+				v[key] = RedactedValue
+				continue
+			}
+			v[key] = RedactCredentials(nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = RedactCredentials(nested)
+		}
+	}
+	return value
+}
+
+// redactedJSON returns the JSON representation of the value with its credentials redacted, it is only meant to
+// be logged.
+func redactedJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return ""
+	}
+
+	data, err = json.Marshal(RedactCredentials(decoded))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// scrubCredentials drops the password decoded with the user, the connector never needs it.
+func (u *User) scrubCredentials() {
+	u.Credentials.Password = ""
+}
+
+// String returns the credentials with the password redacted.
+func (c Credentials) String() string {
+	return fmt.Sprintf("{Login:%s Password:%s}", c.Login, redactedPassword(c.Password))
+}
+
+// GoString keeps the password out of %#v.
+func (c Credentials) GoString() string {
+	return "client.Credentials" + c.String()
+}
+
+// MarshalLogObject logs the credentials with the password redacted.
+func (c Credentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("login", c.Login)
+	enc.AddString("password", redactedPassword(c.Password))
+	return nil
+}
+
+// String returns the registration body with the password redacted.
+func (n NewUserInfo) String() string {
+	return fmt.Sprintf("{Name:%s EmailAddress:%s Password:%s PrivacyPolicyAgreement:%t}",
+		n.Name, n.EmailAddress, redactedPassword(n.Password), n.PrivacyPolicyAgreement)
+}
+
+// GoString keeps the password out of %#v.
+func (n NewUserInfo) GoString() string {
+	return "client.NewUserInfo" + n.String()
+}
+
+// MarshalLogObject logs the registration body with the password redacted.
+func (n NewUserInfo) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", n.Name)
+	enc.AddString("emailAddress", n.EmailAddress)
+	enc.AddString("password", redactedPassword(n.Password))
+	enc.AddBool("privacyPolicyAgreement", n.PrivacyPolicyAgreement)
+	return nil
+}
+
+// redactedPassword tells whether a password is set without revealing it.
+func redactedPassword(password string) string {
+	if password == "" {
+		return ""
+	}
+	return RedactedValue
+}
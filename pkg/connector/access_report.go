@@ -0,0 +1,136 @@
+package connector
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// AccessReport is the users × roles matrix of the tenant, for access reviews.
+type AccessReport struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// DormantAfter is the inactivity after which an administrator is dormant.
+	DormantAfter time.Duration      `json:"-"`
+	Roles        []AccessReportRole `json:"roles"`
+	Users        []AccessReportUser `json:"users"`
+}
+
+type AccessReportRole struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Admin       bool   `json:"admin"`
+}
+
+type AccessReportUser struct {
+	Id                  string    `json:"id"`
+	Name                string    `json:"name"`
+	Email               string    `json:"email"`
+	Realm               string    `json:"realm"`
+	LastActivity        time.Time `json:"lastActivity"`
+	ManualRoles         []string  `json:"manualRoles"`
+	AuthenticationRoles []string  `json:"authenticationRoles"`
+	DefaultRoles        []string  `json:"defaultRoles"`
+	// AdminRoles are the administration roles the user holds, whatever their type.
+	AdminRoles []string `json:"adminRoles"`
+	// Dormant is set for administrators without activity for longer than the dormancy threshold.
+	Dormant bool `json:"dormantAdmin"`
+}
+
+// BuildAccessReport reads the users and their roles the same way as the sync, through the user and role builders.
+func BuildAccessReport(ctx context.Context, c client.FluidTopicsClientInterface, now time.Time, dormantAfter time.Duration) (*AccessReport, error) {
+	report := &AccessReport{
+		GeneratedAt:  now,
+		DormantAfter: dormantAfter,
+	}
+
+	roleResources, _, _, err := newRoleBuilder(c, nil).List(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, roleResource := range roleResources {
+		_, roleName, _ := strings.Cut(roleResource.Id.Resource, ":")
+		if slices.ContainsFunc(report.Roles, func(role AccessReportRole) bool { return role.Name == roleName }) {
+			continue
+		}
+		_, admin := adminRoles[roleName]
+		report.Roles = append(report.Roles, AccessReportRole{
+			Name:        roleName,
+			Description: roleResource.Description,
+			Admin:       admin,
+		})
+	}
+	slices.SortFunc(report.Roles, func(a, b AccessReportRole) int { return strings.Compare(a.Name, b.Name) })
+
+	ub := newUserBuilder(c, false, nil, nil)
+	userResources, _, _, err := ub.List(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userResource := range userResources {
+		userTrait, err := rs.GetUserTrait(userResource)
+		if err != nil {
+			return nil, err
+		}
+		profile := userTrait.GetProfile().AsMap()
+
+		user := AccessReportUser{
+			Id:    userResource.Id.Resource,
+			Name:  userResource.DisplayName,
+			Email: profileString(profile, "email_id"),
+			Realm: profileString(profile, "authentication_realm"),
+		}
+		if userTrait.GetLastLogin() != nil {
+			user.LastActivity = userTrait.GetLastLogin().AsTime()
+		}
+
+		grants, _, _, err := ub.Grants(ctx, userResource, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, grant := range grants {
+			roleType, roleName, err := parseEntitlementId(grant.Entitlement.Id)
+			if err != nil {
+				return nil, err
+			}
+			switch roleType {
+			case manualRole:
+				user.ManualRoles = append(user.ManualRoles, roleName)
+			case authenticationRole:
+				user.AuthenticationRoles = append(user.AuthenticationRoles, roleName)
+			case defaultRole:
+				user.DefaultRoles = append(user.DefaultRoles, roleName)
+			}
+			if _, admin := adminRoles[roleName]; admin && !slices.Contains(user.AdminRoles, roleName) {
+				user.AdminRoles = append(user.AdminRoles, roleName)
+			}
+		}
+
+		user.Dormant = len(user.AdminRoles) > 0 && now.Sub(user.LastActivity) > dormantAfter
+
+		report.Users = append(report.Users, user)
+	}
+	slices.SortFunc(report.Users, func(a, b AccessReportUser) int { return strings.Compare(a.Name, b.Name) })
+
+	return report, nil
+}
+
+// DormantAdmins returns the administrators without activity for longer than the dormancy threshold.
+func (r *AccessReport) DormantAdmins() []AccessReportUser {
+	var dormant []AccessReportUser
+	for _, user := range r.Users {
+		if user.Dormant {
+			dormant = append(dormant, user)
+		}
+	}
+	return dormant
+}
+
+func profileString(profile map[string]interface{}, key string) string {
+	value, _ := profile[key].(string)
+	return value
+}
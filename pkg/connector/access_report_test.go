@@ -0,0 +1,93 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAccessReport(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	dormantAdmin := client.User{
+		Id:            "dormant",
+		DisplayName:   "Dormant Admin",
+		Email:         "dormant@x.com",
+		LastLoginDate: now.AddDate(0, 0, -120),
+		AuthenticationIdentifiers: []client.AuthenticationIdentifiers{
+			{Identifier: "dormant@x.com", Realm: "okta"},
+		},
+	}
+	activeAdmin := client.User{
+		Id:            "active",
+		DisplayName:   "Active Admin",
+		Email:         "active@x.com",
+		LastLoginDate: now.AddDate(0, 0, -2),
+		AuthenticationIdentifiers: []client.AuthenticationIdentifiers{
+			{Identifier: "active@x.com", Realm: "okta"},
+		},
+	}
+	reader := client.User{
+		Id:            "reader",
+		DisplayName:   "Reader",
+		Email:         "reader@x.com",
+		LastLoginDate: now.AddDate(0, 0, -300),
+		AuthenticationIdentifiers: []client.AuthenticationIdentifiers{
+			{Identifier: "reader", Realm: "internal"},
+		},
+	}
+
+	mockClient := &client.MockFluidTopicsClient{}
+	users := []client.User{dormantAdmin, activeAdmin, reader}
+	mockClient.On("ListUsers", mock.Anything).Return(users, "", annotations.Annotations{}, nil)
+	for _, user := range users {
+		mockClient.On("GetUserDetails", mock.Anything, user.Id).Return(user, annotations.Annotations{}, nil)
+	}
+	mockClient.On("GetRolesByUserID", mock.Anything, "dormant").Return(client.UserRoles{
+		ManualRoles:         []string{"USERS_ADMIN"},
+		AuthenticationRoles: []string{"USERS_ADMIN", "PRINT_USER"},
+	}, annotations.Annotations{}, nil)
+	mockClient.On("GetRolesByUserID", mock.Anything, "active").Return(client.UserRoles{
+		ManualRoles: []string{"ADMIN"},
+	}, annotations.Annotations{}, nil)
+	mockClient.On("GetRolesByUserID", mock.Anything, "reader").Return(client.UserRoles{
+		DefaultRoles: []string{"PRINT_USER"},
+	}, annotations.Annotations{}, nil)
+
+	report, err := BuildAccessReport(ctx, mockClient, now, 90*24*time.Hour)
+	require.NoError(t, err)
+
+	require.Len(t, report.Users, 3)
+	byId := make(map[string]AccessReportUser)
+	for _, user := range report.Users {
+		byId[user.Id] = user
+	}
+
+	dormant := byId["dormant"]
+	require.Equal(t, "okta", dormant.Realm)
+	require.Equal(t, "dormant@x.com", dormant.Email)
+	require.Equal(t, []string{"USERS_ADMIN"}, dormant.ManualRoles)
+	require.ElementsMatch(t, []string{"USERS_ADMIN", "PRINT_USER"}, dormant.AuthenticationRoles)
+	require.Equal(t, []string{"USERS_ADMIN"}, dormant.AdminRoles)
+	require.True(t, dormant.Dormant)
+
+	require.False(t, byId["active"].Dormant)
+	// Inactive users without administration roles are not dormant administrators.
+	require.False(t, byId["reader"].Dormant)
+	require.Equal(t, []string{"PRINT_USER"}, byId["reader"].DefaultRoles)
+	require.Empty(t, byId["reader"].AdminRoles)
+
+	require.Len(t, report.DormantAdmins(), 1)
+	require.Equal(t, "dormant", report.DormantAdmins()[0].Id)
+
+	for _, role := range report.Roles {
+		_, admin := adminRoles[role.Name]
+		require.Equal(t, admin, role.Admin, role.Name)
+	}
+}
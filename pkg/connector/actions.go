@@ -0,0 +1,170 @@
+package connector
+
+import (
+	"context"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// customActions holds the handlers of the custom actions of the connector.
+type customActions struct {
+	client      client.FluidTopicsClientInterface
+	manualRoles *manualRolesUpdater
+	now         func() time.Time
+	// readOnly only registers the actions that do not change Fluid Topics.
+	readOnly bool
+	// revokeSessionsOnDelete signs the users out before erase_user and merge_users delete their account.
+	revokeSessionsOnDelete bool
+}
+
+type customAction struct {
+	schema  *v2.BatonActionSchema
+	handler actions.ActionHandler
+	// mutating is set for the actions that can change Fluid Topics, even if only when their dry run is disabled.
+	mutating bool
+	// requires are the API key capabilities the action needs.
+	requires []keyCapability
+}
+
+// list returns every custom action with its schema.
+func (a *customActions) list() []customAction {
+	return []customAction{
+		{
+			schema:   findInactiveUsersSchema,
+			handler:  a.findInactiveUsers,
+			requires: []keyCapability{capabilitySyncUsers},
+		},
+		{
+			schema:   stripInactiveRolesSchema,
+			handler:  a.stripInactiveRoles,
+			mutating: true,
+			requires: []keyCapability{capabilitySyncUsers, capabilityManageRoles},
+		},
+		{
+			schema:   eraseUserSchema,
+			handler:  a.eraseUser,
+			mutating: true,
+			requires: []keyCapability{capabilityManageUsers},
+		},
+		{
+			schema:   transferUserContentSchema,
+			handler:  a.transferUserContent,
+			mutating: true,
+			requires: []keyCapability{capabilityManageUsers},
+		},
+		{
+			schema:   revokeSessionsSchema,
+			handler:  a.revokeSessions,
+			mutating: true,
+			requires: []keyCapability{capabilityManageUsers},
+		},
+		{
+			schema:   mergeUsersSchema,
+			handler:  a.mergeUsers,
+			mutating: true,
+			requires: []keyCapability{capabilityManageRoles, capabilityManageUsers},
+		},
+	}
+}
+
+// newActionManager registers the custom actions the API key capabilities allow in a new action manager.
+func (a *customActions) newActionManager(ctx context.Context, capabilities keyCapabilities) (connectorbuilder.CustomActionManager, error) {
+	manager := actions.NewActionManager(ctx)
+
+	for _, action := range a.list() {
+		if a.readOnly && action.mutating {
+			continue
+		}
+		if !capabilities.hasAll(action.requires) {
+			continue
+		}
+
+		err := manager.RegisterAction(ctx, action.schema.Name, action.schema, withAuditTask(action.schema.Name, action.handler))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return manager, nil
+}
+
+func stringArgument(name string, displayName string, description string, required bool) *configv1.Field {
+	return &configv1.Field{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		IsRequired:  required,
+		Field:       &configv1.Field_StringField{StringField: &configv1.StringField{}},
+	}
+}
+
+func intArgument(name string, displayName string, description string, required bool) *configv1.Field {
+	return &configv1.Field{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		IsRequired:  required,
+		Field:       &configv1.Field_IntField{IntField: &configv1.IntField{}},
+	}
+}
+
+func boolArgument(name string, displayName string, description string, defaultValue bool) *configv1.Field {
+	return &configv1.Field{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		Field:       &configv1.Field_BoolField{BoolField: &configv1.BoolField{DefaultValue: defaultValue}},
+	}
+}
+
+// stringArg returns the string argument, or an empty string when it is missing.
+func stringArg(args *structpb.Struct, name string) string {
+	return args.GetFields()[name].GetStringValue()
+}
+
+// intArg returns the number argument, and false when it is missing.
+func intArg(args *structpb.Struct, name string) (int, bool) {
+	value, ok := args.GetFields()[name]
+	if !ok {
+		return 0, false
+	}
+	if _, isNumber := value.GetKind().(*structpb.Value_NumberValue); !isNumber {
+		return 0, false
+	}
+	return int(value.GetNumberValue()), true
+}
+
+// boolArg returns the boolean argument, or the default value when it is missing.
+func boolArg(args *structpb.Struct, name string, defaultValue bool) bool {
+	value, ok := args.GetFields()[name]
+	if !ok {
+		return defaultValue
+	}
+	if _, isBool := value.GetKind().(*structpb.Value_BoolValue); !isBool {
+		return defaultValue
+	}
+	return value.GetBoolValue()
+}
+
+// toList converts the slice so it can be set in a structpb value.
+func toList[T any](values []T) []interface{} {
+	list := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		list = append(list, value)
+	}
+	return list
+}
+
+func newCustomActions(c client.FluidTopicsClientInterface, manualRoles *manualRolesUpdater) *customActions {
+	return &customActions{
+		client:      c,
+		manualRoles: manualRoles,
+		now:         time.Now,
+	}
+}
@@ -0,0 +1,25 @@
+package connector
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Types of the tasks the requests are recorded for in the audit log.
+const (
+	auditTaskGrant         = "grant"
+	auditTaskRevoke        = "revoke"
+	auditTaskCreateAccount = "create_account"
+	auditTaskAction        = "action"
+)
+
+// withAuditTask records the requests of the action handler in the audit log as made for the action.
+func withAuditTask(name string, handler actions.ActionHandler) actions.ActionHandler {
+	return func(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+		return handler(client.WithAuditTask(ctx, auditTaskAction, name), args)
+	}
+}
@@ -0,0 +1,213 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	RoleChangeAdd    = "add"
+	RoleChangeRemove = "remove"
+
+	defaultBulkRolesConcurrency = 4
+)
+
+// RoleChange adds or removes a manual role of a user, the user is given by its ID or its email.
+type RoleChange struct {
+	User   string
+	Role   string
+	Action string
+}
+
+// BulkRolesResult is the outcome of the role changes of a user.
+type BulkRolesResult struct {
+	UserID string
+	// User is the user as given in the role changes, an email when it had to be resolved.
+	User    string
+	Added   []string
+	Removed []string
+	// Skipped explains why nothing was applied, either because the user already had the requested roles or
+	// because the changes are invalid.
+	Skipped string
+	Err     error
+}
+
+// ApplyBulkRoles groups the role changes by user and applies each user's manual roles diff in a single update.
+// Users are updated concurrently, at most concurrency at a time. Nothing is written in dry run. When the context is
+// canceled, the results are returned along with the error, the users not updated yet failing with it.
+func ApplyBulkRoles(
+	ctx context.Context,
+	c client.FluidTopicsClientInterface,
+	changes []RoleChange,
+	concurrency int,
+	dryRun bool,
+) ([]BulkRolesResult, error) {
+	l := ctxzap.Extract(ctx)
+
+	if concurrency <= 0 {
+		concurrency = defaultBulkRolesConcurrency
+	}
+
+	userIDs, err := resolveUserIDs(ctx, c, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	results, diffs := planBulkRoles(changes, userIDs)
+
+	manualRoles := newManualRolesUpdater(c)
+	sem := semaphore.NewWeighted(int64(concurrency))
+	var wg sync.WaitGroup
+	var canceled error
+	for i := range diffs {
+		diff := &diffs[i]
+		if err := sem.Acquire(ctx, 1); err != nil {
+			// The updates already started still write their diff, the others are not started.
+			canceled = err
+			for j := i; j < len(diffs); j++ {
+				diffs[j].result.Err = fmt.Errorf("not updated: %w", err)
+			}
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sem.Release(1)
+			diff.apply(ctx, c, manualRoles, dryRun)
+		}()
+	}
+	wg.Wait()
+
+	for _, diff := range diffs {
+		if diff.result.Err != nil {
+			l.Error("error applying bulk roles", zap.String("user_id", diff.result.UserID), zap.Error(diff.result.Err))
+		}
+		results = append(results, diff.result)
+	}
+
+	return results, canceled
+}
+
+// bulkRolesDiff is the manual roles requested to be added to and removed from a user.
+type bulkRolesDiff struct {
+	add    []string
+	remove []string
+	result BulkRolesResult
+}
+
+// apply computes the diff against the current manual roles of the user and writes it.
+func (d *bulkRolesDiff) apply(ctx context.Context, c client.FluidTopicsClientInterface, manualRoles *manualRolesUpdater, dryRun bool) {
+	userRoles, _, err := c.GetRolesByUserID(ctx, d.result.UserID)
+	if err != nil {
+		d.result.Err = err
+		return
+	}
+
+	for _, role := range d.add {
+		if !slices.Contains(userRoles.ManualRoles, role) {
+			d.result.Added = append(d.result.Added, role)
+		}
+	}
+	for _, role := range d.remove {
+		if slices.Contains(userRoles.ManualRoles, role) {
+			d.result.Removed = append(d.result.Removed, role)
+		}
+	}
+
+	if len(d.result.Added) == 0 && len(d.result.Removed) == 0 {
+		d.result.Skipped = "no change"
+		return
+	}
+	if dryRun {
+		return
+	}
+
+	if _, _, err := manualRoles.update(ctx, d.result.UserID, d.result.Added, d.result.Removed); err != nil {
+		d.result.Err = err
+	}
+}
+
+// planBulkRoles returns the results of the invalid changes, and the diff of every user with valid changes.
+func planBulkRoles(changes []RoleChange, userIDs map[string]string) ([]BulkRolesResult, []bulkRolesDiff) {
+	var results []BulkRolesResult
+	var diffs []bulkRolesDiff
+	byUser := make(map[string]int)
+
+	for _, change := range changes {
+		skip := func(reason string) {
+			results = append(results, BulkRolesResult{User: change.User, Skipped: reason})
+		}
+
+		userID, ok := userIDs[strings.ToLower(change.User)]
+		if !ok {
+			skip("unknown user")
+			continue
+		}
+		if !slices.Contains(allRoleNames(), change.Role) {
+			skip(fmt.Sprintf("unknown role %s", change.Role))
+			continue
+		}
+		action := strings.ToLower(change.Action)
+		if action != RoleChangeAdd && action != RoleChangeRemove {
+			skip(fmt.Sprintf("unknown action %s", change.Action))
+			continue
+		}
+
+		idx, ok := byUser[userID]
+		if !ok {
+			idx = len(diffs)
+			byUser[userID] = idx
+			diffs = append(diffs, bulkRolesDiff{result: BulkRolesResult{UserID: userID, User: change.User}})
+		}
+		diff := &diffs[idx]
+
+		// A later change of the same role wins.
+		diff.add = slices.DeleteFunc(diff.add, func(role string) bool { return role == change.Role })
+		diff.remove = slices.DeleteFunc(diff.remove, func(role string) bool { return role == change.Role })
+		if action == RoleChangeAdd {
+			diff.add = append(diff.add, change.Role)
+		} else {
+			diff.remove = append(diff.remove, change.Role)
+		}
+	}
+
+	return results, diffs
+}
+
+// resolveUserIDs maps the users of the changes, lower cased, to their IDs. Emails are resolved from the users
+// listing, which is only read when a change refers to a user by email.
+func resolveUserIDs(ctx context.Context, c client.FluidTopicsClientInterface, changes []RoleChange) (map[string]string, error) {
+	userIDs := make(map[string]string)
+
+	needsListing := false
+	for _, change := range changes {
+		if strings.Contains(change.User, "@") {
+			needsListing = true
+			continue
+		}
+		userIDs[strings.ToLower(change.User)] = change.User
+	}
+	if !needsListing {
+		return userIDs, nil
+	}
+
+	users, _, _, err := c.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if user.Email != "" {
+			userIDs[strings.ToLower(user.Email)] = user.Id
+		}
+	}
+
+	return userIDs, nil
+}
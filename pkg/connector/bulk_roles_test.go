@@ -0,0 +1,100 @@
+package connector
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyBulkRoles(t *testing.T) {
+	ctx := context.Background()
+
+	changes := []RoleChange{
+		{User: "Jane@x.com", Role: "PRINT_USER", Action: "add"},
+		{User: "jane@x.com", Role: "ADMIN", Action: "remove"},
+		{User: "user-2", Role: "PRINT_USER", Action: "add"},
+		{User: "ghost@x.com", Role: "PRINT_USER", Action: "add"},
+		{User: "user-2", Role: "NOT_A_ROLE", Action: "add"},
+	}
+
+	newClient := func() *client.MockFluidTopicsClient {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("ListUsers", mock.Anything).
+			Return([]client.User{{Id: "user-1", Email: "jane@x.com"}, {Id: "user-2"}}, "", annotations.Annotations{}, nil)
+		mockClient.On("GetRolesByUserID", mock.Anything, "user-2").
+			Return(client.UserRoles{ManualRoles: []string{"PRINT_USER"}}, annotations.Annotations{}, nil)
+		return mockClient
+	}
+
+	resultsByUser := func(results []BulkRolesResult) map[string]BulkRolesResult {
+		byUser := make(map[string]BulkRolesResult)
+		for _, result := range results {
+			byUser[result.User] = result
+		}
+		return byUser
+	}
+
+	t.Run("Dry run computes the diff of every user", func(t *testing.T) {
+		mockClient := newClient()
+		mockClient.On("GetRolesByUserID", mock.Anything, "user-1").
+			Return(client.UserRoles{ManualRoles: []string{"ADMIN"}}, annotations.Annotations{}, nil)
+
+		results, err := ApplyBulkRoles(ctx, mockClient, changes, 2, true)
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+
+		byUser := resultsByUser(results)
+		require.Equal(t, "user-1", byUser["Jane@x.com"].UserID)
+		require.Equal(t, []string{"PRINT_USER"}, byUser["Jane@x.com"].Added)
+		require.Equal(t, []string{"ADMIN"}, byUser["Jane@x.com"].Removed)
+		require.Equal(t, "no change", byUser["user-2"].Skipped)
+		require.Equal(t, "unknown user", byUser["ghost@x.com"].Skipped)
+		mockClient.AssertNotCalled(t, "UpdateUserManualRoles", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Applies the diff in a single update per user", func(t *testing.T) {
+		mockClient := newClient()
+		mockClient.On("GetRolesByUserID", mock.Anything, "user-1").
+			Return(client.UserRoles{ManualRoles: []string{"ADMIN"}}, annotations.Annotations{}, nil).Twice()
+		mockClient.On("UpdateUserManualRoles", mock.Anything, "user-1", []string{"PRINT_USER"}).
+			Return(annotations.Annotations{}, nil).Once()
+		mockClient.On("GetRolesByUserID", mock.Anything, "user-1").
+			Return(client.UserRoles{ManualRoles: []string{"PRINT_USER"}}, annotations.Annotations{}, nil).Once()
+
+		results, err := ApplyBulkRoles(ctx, mockClient, changes, 2, false)
+		require.NoError(t, err)
+		require.NoError(t, resultsByUser(results)["Jane@x.com"].Err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Cancellation waits for the updates already started", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var finished atomic.Bool
+		mockClient := newClient()
+		mockClient.On("GetRolesByUserID", mock.Anything, "user-1").
+			Run(func(mock.Arguments) {
+				cancel()
+				time.Sleep(50 * time.Millisecond)
+				finished.Store(true)
+			}).
+			Return(client.UserRoles{ManualRoles: []string{"ADMIN"}}, annotations.Annotations{}, nil)
+
+		results, err := ApplyBulkRoles(ctx, mockClient, changes, 1, true)
+		require.ErrorIs(t, err, context.Canceled)
+		require.True(t, finished.Load())
+
+		// The results are returned along with the error, the users not updated fail with it.
+		require.Len(t, results, 4)
+		byUser := resultsByUser(results)
+		require.NoError(t, byUser["Jane@x.com"].Err)
+		require.ErrorIs(t, byUser["user-2"].Err, context.Canceled)
+	})
+}
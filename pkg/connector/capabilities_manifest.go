@@ -0,0 +1,43 @@
+package connector
+
+//go:generate go run gen_capabilities.go ../../baton_capabilities.json
+
+import (
+	"context"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// CapabilitiesManifest returns the baton_capabilities.json manifest: the capabilities connectorbuilder advertises for
+// the connector with its default configuration and an API key with every capability, in the format of the SDK
+// capabilities subcommand. It makes no request to Fluid Topics.
+func CapabilitiesManifest(ctx context.Context) ([]byte, error) {
+	d := &Connector{
+		capabilities: allCapabilities(),
+		events:       newEventFeed(nil),
+		userDumps:    newUserDumps(nil),
+		actions:      newCustomActions(nil, nil),
+	}
+
+	server, err := connectorbuilder.NewConnector(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := server.GetMetadata(ctx, &v2.ConnectorServiceGetMetadataRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &anypb.Any{}
+	err = anypb.MarshalFrom(manifest, metadata.Metadata.Capabilities, proto.MarshalOptions{Deterministic: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(manifest)
+}
@@ -0,0 +1,37 @@
+package connector
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func decodeCapabilitiesManifest(t *testing.T, data []byte) *v2.ConnectorCapabilities {
+	manifest := &anypb.Any{}
+	require.NoError(t, protojson.Unmarshal(data, manifest))
+
+	capabilities := &v2.ConnectorCapabilities{}
+	require.NoError(t, manifest.UnmarshalTo(capabilities))
+	return capabilities
+}
+
+// TestCapabilitiesManifestUpToDate fails when baton_capabilities.json no longer matches the capabilities of the code.
+// The manifests are compared decoded, protojson does not guarantee a stable output.
+func TestCapabilitiesManifestUpToDate(t *testing.T) {
+	generated, err := CapabilitiesManifest(context.Background())
+	require.NoError(t, err)
+
+	committed, err := os.ReadFile("../../baton_capabilities.json")
+	require.NoError(t, err)
+
+	want := decodeCapabilitiesManifest(t, generated)
+	got := decodeCapabilitiesManifest(t, committed)
+	require.True(t, proto.Equal(want, got),
+		"baton_capabilities.json is out of date, run go generate ./pkg/connector\nwant:\n%s", generated)
+}
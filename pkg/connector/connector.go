@@ -4,35 +4,89 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/conductorone/baton-fluid-topics/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/metrics"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Connector struct {
-	client *client.FluidTopicsClient
+	client      client.FluidTopicsClientInterface
+	domain      string
+	manualRoles *manualRolesUpdater
+	events      *eventFeed
+	userDumps   *userDumps
+	actions     *customActions
+
+	userActivityMetrics    bool
+	syncState              *userSyncState
+	revokeSessionsOnDelete bool
+	dryRun                 bool
+	metricsHandler         metrics.Handler
+	auditLogPath           string
+	readOnly               bool
+	syncMetrics            *syncMetrics
+
+	capabilitiesMtx sync.Mutex
+	capabilities    keyCapabilities
+}
+
+// Option configures an optional behavior of the connector.
+type Option func(*Connector)
+
+// WithUserActivityMetrics adds the usage metrics of the last 30 and 90 days from the analytics to the user profiles.
+func WithUserActivityMetrics(enabled bool) Option {
+	return func(c *Connector) {
+		c.userActivityMetrics = enabled
+	}
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
+// The syncers and provisioners the API key cannot use are left out, all of them are kept when its roles cannot be read.
 func (d *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
-	return []connectorbuilder.ResourceSyncer{
-		newUserBuilder(d.client),
-		newRoleBuilder(d.client),
-	}
+	return d.resourceSyncers(ctx, d.keyCapabilitiesOrAll(ctx))
 }
 
 // Asset takes an input AssetRef and attempts to fetch it using the connector's authenticated http client
 // It streams a response, always starting with a metadata object, following by chunked payloads for the asset.
+// The only assets are the personal data dumps of the users, referenced from their profiles.
 func (d *Connector) Asset(ctx context.Context, asset *v2.AssetRef) (string, io.ReadCloser, error) {
-	return "", nil, nil
+	return d.userDumps.Asset(ctx, asset)
+}
+
+// ListEvents returns the usage events of the Fluid Topics users, and the grant and revoke events of their manual roles.
+// The usage events are left out when the API key cannot read the analytics.
+func (d *Connector) ListEvents(
+	ctx context.Context,
+	earliestEvent *timestamppb.Timestamp,
+	pToken *pagination.StreamToken,
+) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	usage := d.keyCapabilitiesOrAll(ctx).has(capabilityReadAnalytics)
+	return d.events.ListEvents(ctx, earliestEvent, pToken, usage)
 }
 
-// Metadata returns metadata about the connector.
+// RegisterActionManager returns the manager of the custom actions the API key capabilities allow.
+func (d *Connector) RegisterActionManager(ctx context.Context) (connectorbuilder.CustomActionManager, error) {
+	return d.actions.newActionManager(ctx, d.keyCapabilitiesOrAll(ctx))
+}
+
+// Metadata returns metadata about the connector. Accounts cannot be created in read-only mode.
 func (d *Connector) Metadata(_ context.Context) (*v2.ConnectorMetadata, error) {
+	if d.readOnly {
+		return &v2.ConnectorMetadata{
+			DisplayName: "Fluid Topics Connector",
+			Description: "Connector to sync users in Fluid Topics, read-only.",
+		}, nil
+	}
+
 	return &v2.ConnectorMetadata{
 		DisplayName: "Fluid Topics Connector",
 		Description: "Connector to sync and manage users in Fluid Topics.",
@@ -64,33 +118,119 @@ func (d *Connector) Metadata(_ context.Context) (*v2.ConnectorMetadata, error) {
 }
 
 // Validate is called to ensure that the connector is properly configured. It should exercise any API credentials
-// to be sure that they are valid.
+// to be sure that they are valid. The API key only needs to sync users, the capabilities it lacks are logged.
 func (d *Connector) Validate(ctx context.Context) (annotations.Annotations, error) {
-	roles, annotation, err := d.client.GetAuthenticationInfo(ctx)
+	capabilities, err := d.keyCapabilities(ctx)
 	if err != nil {
-		return annotation, fmt.Errorf("could not fetch current user roles: %w", err)
+		return nil, err
+	}
+
+	logMissingCapabilities(ctx, capabilities)
+
+	if !capabilities.has(capabilitySyncUsers) {
+		return nil, fmt.Errorf("authentication user must have the ADMIN or USERS_ADMIN role to use this connector")
 	}
 
-	for _, role := range roles.Profile.Roles {
-		if role == "ADMIN" {
-			return annotation, nil
+	return nil, nil
+}
+
+// WithIncrementalSync keeps the state of each users sync in the file at statePath, so the next sync only fetches the
+// details of the users whose listing changed, the roles of every user are still fetched. All users are fetched again
+// every fullResyncInterval.
+func WithIncrementalSync(statePath string, fullResyncInterval time.Duration) Option {
+	return func(c *Connector) {
+		if statePath != "" {
+			c.syncState = newUserSyncState(statePath, fullResyncInterval)
 		}
 	}
+}
+
+// WithRevokeSessionsOnDelete revokes the sessions of a user before erase_user or merge_users deletes its account.
+func WithRevokeSessionsOnDelete(enabled bool) Option {
+	return func(c *Connector) {
+		c.revokeSessionsOnDelete = enabled
+	}
+}
+
+// WithDryRun logs the changes the connector would make to Fluid Topics instead of making them. The reads are still
+// made, so grants, revocations and account creations report the changes they would apply.
+func WithDryRun(enabled bool) Option {
+	return func(c *Connector) {
+		c.dryRun = enabled
+	}
+}
+
+// WithMetricsHandler records the metrics of the requests made to Fluid Topics and of the users sync with h.
+func WithMetricsHandler(h metrics.Handler) Option {
+	return func(c *Connector) {
+		c.metricsHandler = h
+	}
+}
+
+// WithAuditLog appends every request changing Fluid Topics to the JSONL file at path, with the task it was made for,
+// its target user, the manual roles before and after and its outcome. Credentials are redacted.
+func WithAuditLog(path string) Option {
+	return func(c *Connector) {
+		c.auditLogPath = path
+	}
+}
 
-	return annotation, fmt.Errorf("authentication user must have ADMIN role to use this connector")
+// WithReadOnly keeps the connector from changing Fluid Topics: it only syncs, the provisioning and the custom actions
+// changing Fluid Topics are not registered, and the client refuses every request that could change it.
+func WithReadOnly(enabled bool) Option {
+	return func(c *Connector) {
+		c.readOnly = enabled
+	}
 }
 
 // New returns a new instance of the connector.
-func New(ctx context.Context, fluidTopicsBearerToken string, fluidTopicsDomain string) (*Connector, error) {
+func New(ctx context.Context, fluidTopicsBearerToken string, fluidTopicsDomain string, opts ...Option) (*Connector, error) {
 	l := ctxzap.Extract(ctx)
 
-	fluidTopicClient, err := client.New(ctx, fluidTopicsBearerToken, fluidTopicsDomain)
+	c := &Connector{
+		domain: fluidTopicsDomain,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	clientOpts := []client.Option{client.WithDryRun(c.dryRun), client.WithReadOnly(c.readOnly)}
+	if c.metricsHandler != nil {
+		clientOpts = append(clientOpts, client.WithMetricsHandler(c.metricsHandler))
+	}
+	if c.auditLogPath != "" {
+		auditLog, err := client.NewAuditLog(c.auditLogPath)
+		if err != nil {
+			l.Error("error opening the audit log", zap.Error(err))
+			return nil, err
+		}
+		clientOpts = append(clientOpts, client.WithAuditLog(auditLog))
+	}
+
+	fluidTopicClient, err := client.New(ctx, fluidTopicsBearerToken, fluidTopicsDomain, clientOpts...)
 	if err != nil {
 		l.Error("error creating Fluid Topics client", zap.Error(err))
 		return nil, err
 	}
 
-	return &Connector{
-		client: fluidTopicClient,
-	}, nil
+	manualRoles := newManualRolesUpdater(fluidTopicClient)
+	manualRoles.dryRun = c.dryRun
+
+	c.client = fluidTopicClient
+	c.manualRoles = manualRoles
+	c.events = newEventFeed(fluidTopicClient)
+	c.userDumps = newUserDumps(fluidTopicClient)
+	c.actions = newCustomActions(fluidTopicClient, manualRoles)
+	c.actions.readOnly = c.readOnly
+	c.actions.revokeSessionsOnDelete = c.revokeSessionsOnDelete
+	c.syncMetrics = newSyncMetrics(ctx, c.metricsHandler)
+
+	if c.dryRun {
+		l.Info("dry run, no change will be made to Fluid Topics")
+	}
+	if c.readOnly {
+		l.Info("read-only mode, only syncs are enabled")
+	}
+
+	return c, nil
 }
@@ -0,0 +1,194 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// duplicateUsers holds, for every user, the other users sharing its email or one of its identifiers.
+type duplicateUsers map[string][]string
+
+// findDuplicateUsers groups the users by email and by authentication identifier, ignoring case. The same person
+// usually shows up twice as an internal account and an account from an identity provider.
+func findDuplicateUsers(users []client.User) duplicateUsers {
+	byKey := make(map[string][]string)
+	addKey := func(key string, userID string) {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" || slices.Contains(byKey[key], userID) {
+			return
+		}
+		byKey[key] = append(byKey[key], userID)
+	}
+
+	for _, user := range users {
+		addKey(user.Email, user.Id)
+		for _, identifier := range user.AuthenticationIdentifiers {
+			addKey(identifier.Identifier, user.Id)
+		}
+	}
+
+	duplicates := make(duplicateUsers)
+	for _, userIDs := range byKey {
+		if len(userIDs) < 2 {
+			continue
+		}
+		for _, userID := range userIDs {
+			for _, otherID := range userIDs {
+				if otherID != userID && !slices.Contains(duplicates[userID], otherID) {
+					duplicates[userID] = append(duplicates[userID], otherID)
+				}
+			}
+		}
+	}
+
+	for userID := range duplicates {
+		slices.Sort(duplicates[userID])
+	}
+
+	return duplicates
+}
+
+var mergeUsersSchema = &v2.BatonActionSchema{
+	Name:        "merge_users",
+	DisplayName: "Merge users",
+	Description: "Moves the manual roles, manual groups and personal content of a duplicate account to the surviving " +
+		"account, then deletes the duplicate. The duplicate is kept when any step fails. Nothing is changed unless " +
+		"dry_run is false and confirm repeats the duplicate ID.",
+	Arguments: []*configv1.Field{
+		stringArgument("duplicate_id", "Duplicate ID", "ID of the account to merge and delete.", true),
+		stringArgument("survivor_id", "Survivor ID", "ID of the account to keep.", true),
+		stringArgument("confirm", "Confirmation", "Must be the ID of the duplicate to delete.", false),
+		boolArgument("dry_run", "Dry run", "Only report what would be merged. Enabled unless explicitly disabled.", true),
+	},
+	ReturnTypes: []*configv1.Field{
+		boolArgument("completed", "Completed", "Whether the duplicate was merged and deleted.", false),
+	},
+}
+
+// mergeUsers consolidates the duplicate account onto the survivor. Authentication roles and groups come from the
+// realm of each account, only the manual ones are moved.
+func (a *customActions) mergeUsers(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	duplicateID := stringArg(args, "duplicate_id")
+	survivorID := stringArg(args, "survivor_id")
+	if duplicateID == "" || survivorID == "" {
+		return nil, nil, fmt.Errorf("duplicate_id and survivor_id are required")
+	}
+	if duplicateID == survivorID {
+		return nil, nil, fmt.Errorf("the survivor must be another user")
+	}
+	dryRun := boolArg(args, "dry_run", true)
+	if !dryRun && stringArg(args, "confirm") != duplicateID {
+		return nil, nil, fmt.Errorf("confirm must be the ID of the duplicate to delete")
+	}
+
+	for _, userID := range []string{duplicateID, survivorID} {
+		if _, _, err := a.client.GetUserDetails(ctx, userID); err != nil {
+			return nil, nil, fmt.Errorf("error getting user %s: %w", userID, err)
+		}
+	}
+
+	var failures []interface{}
+	fail := func(step string, err error) {
+		l.Error("error merging users", zap.String("duplicate_id", duplicateID), zap.String("step", step), zap.Error(err))
+		failures = append(failures, map[string]interface{}{
+			"step":  step,
+			"error": err.Error(),
+		})
+	}
+
+	duplicateRoles, _, err := a.client.GetRolesByUserID(ctx, duplicateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !dryRun && len(duplicateRoles.ManualRoles) > 0 {
+		if _, _, err := a.manualRoles.update(ctx, survivorID, duplicateRoles.ManualRoles, nil); err != nil {
+			fail("manual_roles", err)
+		}
+	}
+
+	duplicateGroups, _, err := a.client.GetGroupsByUserID(ctx, duplicateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !dryRun && len(duplicateGroups.ManualGroups) > 0 {
+		if err := a.addManualGroups(ctx, survivorID, duplicateGroups.ManualGroups); err != nil {
+			fail("manual_groups", err)
+		}
+	}
+
+	content, contentFailures, err := a.transferContent(ctx, duplicateID, survivorID, client.UserContentTransferMove, dryRun)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, contentFailure := range contentFailures {
+		failures = append(failures, contentFailure)
+	}
+
+	if !dryRun && len(failures) == 0 && a.revokeSessionsOnDelete {
+		if _, _, err := revokeUserSessions(ctx, a.client, duplicateID); err != nil {
+			fail("duplicate_sessions", err)
+		}
+	}
+
+	deleted := false
+	if !dryRun && len(failures) == 0 {
+		if _, err := a.client.DeleteUser(ctx, duplicateID); err != nil {
+			fail("delete_duplicate", err)
+		} else {
+			deleted = true
+		}
+	}
+
+	ret, err := structpb.NewStruct(map[string]interface{}{
+		"duplicate_id":  duplicateID,
+		"survivor_id":   survivorID,
+		"dry_run":       dryRun,
+		"completed":     deleted,
+		"manual_roles":  toList(duplicateRoles.ManualRoles),
+		"manual_groups": toList(duplicateGroups.ManualGroups),
+		"content":       content,
+		"failures":      failures,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ret, nil, nil
+}
+
+// addManualGroups adds the groups to the manual groups of the user. Like the manual roles, the manual groups are
+// replaced as a whole, so the update holds the lock of the user.
+func (a *customActions) addManualGroups(ctx context.Context, userID string, groups []string) error {
+	unlock := a.manualRoles.locks.lock(userID)
+	defer unlock()
+
+	userGroups, _, err := a.client.GetGroupsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	merged := slices.Clone(userGroups.ManualGroups)
+	for _, group := range groups {
+		if !slices.Contains(merged, group) {
+			merged = append(merged, group)
+		}
+	}
+	if len(merged) == len(userGroups.ManualGroups) {
+		return nil
+	}
+
+	_, err = a.client.UpdateUserManualGroups(ctx, userID, merged)
+	return err
+}
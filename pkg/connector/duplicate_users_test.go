@@ -0,0 +1,135 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFindDuplicateUsers(t *testing.T) {
+	duplicates := findDuplicateUsers([]client.User{
+		{Id: "internal", Email: "Jane@x.com"},
+		{
+			Id:    "saml",
+			Email: "jane@x.com",
+			AuthenticationIdentifiers: []client.AuthenticationIdentifiers{
+				{Identifier: "jdoe", Realm: "okta"},
+			},
+		},
+		{
+			Id: "ldap",
+			AuthenticationIdentifiers: []client.AuthenticationIdentifiers{
+				{Identifier: "JDOE", Realm: "ldap"},
+			},
+		},
+		{Id: "other", Email: "other@x.com"},
+	})
+
+	require.Equal(t, []string{"saml"}, duplicates["internal"])
+	require.Equal(t, []string{"internal", "ldap"}, duplicates["saml"])
+	require.Equal(t, []string{"saml"}, duplicates["ldap"])
+	require.NotContains(t, duplicates, "other")
+}
+
+func TestCustomActions_MergeUsers(t *testing.T) {
+	ctx := context.Background()
+
+	newActions := func() (*customActions, *client.MockFluidTopicsClient) {
+		mockClient := &client.MockFluidTopicsClient{}
+		for _, userID := range []string{"duplicate", "survivor"} {
+			mockClient.On("GetUserDetails", mock.Anything, userID).Return(client.User{Id: userID}, annotations.Annotations{}, nil)
+		}
+		mockClient.On("GetRolesByUserID", mock.Anything, "duplicate").
+			Return(client.UserRoles{ManualRoles: []string{"PRINT_USER"}}, annotations.Annotations{}, nil)
+		mockClient.On("GetGroupsByUserID", mock.Anything, "duplicate").
+			Return(client.UserGroups{ManualGroups: []string{"writers"}}, annotations.Annotations{}, nil)
+		for _, kind := range transferredUserContents {
+			items := []client.UserContentItem{}
+			if kind == client.UserContentPersonalBooks {
+				items = append(items, client.UserContentItem{Id: "book-1"})
+			}
+			mockClient.On("ListUserContent", mock.Anything, "duplicate", kind).Return(items, annotations.Annotations{}, nil)
+		}
+		return newCustomActions(mockClient, newManualRolesUpdater(mockClient)), mockClient
+	}
+
+	t.Run("Dry run only reports", func(t *testing.T) {
+		a, mockClient := newActions()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"duplicate_id": "duplicate", "survivor_id": "survivor"})
+		require.NoError(t, err)
+		res, _, err := a.mergeUsers(ctx, args)
+		require.NoError(t, err)
+		require.False(t, res.Fields["completed"].GetBoolValue())
+		require.Len(t, res.Fields["content"].GetListValue().GetValues(), 1)
+		mockClient.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Moves roles, groups and content then deletes the duplicate", func(t *testing.T) {
+		a, mockClient := newActions()
+		mockClient.On("GetRolesByUserID", mock.Anything, "survivor").
+			Return(client.UserRoles{ManualRoles: []string{"ADMIN"}}, annotations.Annotations{}, nil).Once()
+		mockClient.On("UpdateUserManualRoles", mock.Anything, "survivor", []string{"ADMIN", "PRINT_USER"}).
+			Return(annotations.Annotations{}, nil).Once()
+		mockClient.On("GetRolesByUserID", mock.Anything, "survivor").
+			Return(client.UserRoles{ManualRoles: []string{"ADMIN", "PRINT_USER"}}, annotations.Annotations{}, nil).Once()
+		mockClient.On("GetGroupsByUserID", mock.Anything, "survivor").
+			Return(client.UserGroups{ManualGroups: []string{"customers"}}, annotations.Annotations{}, nil).Once()
+		mockClient.On("UpdateUserManualGroups", mock.Anything, "survivor", []string{"customers", "writers"}).
+			Return(annotations.Annotations{}, nil).Once()
+		mockClient.On("TransferUserContent", mock.Anything, "duplicate", client.UserContentPersonalBooks, "book-1",
+			client.UserContentTransferRequest{TargetUserId: "survivor", Mode: client.UserContentTransferMove}).
+			Return(annotations.Annotations{}, nil).Once()
+		mockClient.On("DeleteUser", mock.Anything, "duplicate").Return(annotations.Annotations{}, nil).Once()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"duplicate_id": "duplicate", "survivor_id": "survivor", "dry_run": false, "confirm": "duplicate"})
+		require.NoError(t, err)
+		res, _, err := a.mergeUsers(ctx, args)
+		require.NoError(t, err)
+		require.True(t, res.Fields["completed"].GetBoolValue())
+		require.Empty(t, res.Fields["failures"].GetListValue().GetValues())
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Requires the confirmation", func(t *testing.T) {
+		a, mockClient := newActions()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"duplicate_id": "duplicate", "survivor_id": "survivor", "dry_run": false, "confirm": "survivor"})
+		require.NoError(t, err)
+		_, _, err = a.mergeUsers(ctx, args)
+		require.ErrorContains(t, err, "confirm")
+		mockClient.AssertNotCalled(t, "GetUserDetails", mock.Anything, mock.Anything)
+	})
+
+	t.Run("The manual groups update waits for the lock of the user", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		a := newCustomActions(mockClient, newManualRolesUpdater(mockClient))
+		mockClient.On("GetGroupsByUserID", mock.Anything, "survivor").
+			Return(client.UserGroups{}, annotations.Annotations{}, nil).Once()
+		mockClient.On("UpdateUserManualGroups", mock.Anything, "survivor", []string{"writers"}).
+			Return(annotations.Annotations{}, nil).Once()
+
+		unlock := a.manualRoles.locks.lock("survivor")
+		done := make(chan error, 1)
+		go func() {
+			done <- a.addManualGroups(ctx, "survivor", []string{"writers"})
+		}()
+
+		select {
+		case <-done:
+			require.Fail(t, "the manual groups were updated while the user was locked")
+		case <-time.After(20 * time.Millisecond):
+		}
+		mockClient.AssertNotCalled(t, "GetGroupsByUserID", mock.Anything, "survivor")
+
+		unlock()
+		require.NoError(t, <-done)
+		mockClient.AssertExpectations(t)
+	})
+}
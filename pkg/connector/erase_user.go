@@ -0,0 +1,166 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The personal content is deleted in this order, before the feedback is anonymized and the account deleted.
+var erasedUserContents = []string{
+	client.UserContentPersonalBooks,
+	client.UserContentCollections,
+	client.UserContentBookmarks,
+	client.UserContentSavedSearches,
+}
+
+var eraseUserSchema = &v2.BatonActionSchema{
+	Name:        "erase_user",
+	DisplayName: "Erase user",
+	Description: "Deletes the personal content of the user, anonymizes its feedback, ratings and analytics, " +
+		"then deletes its account. Nothing is changed unless dry_run is false and confirm repeats the user ID.",
+	Arguments: []*configv1.Field{
+		stringArgument("user_id", "User ID", "ID of the user to erase.", true),
+		stringArgument("confirm", "Confirmation", "Must be the ID of the user to erase.", false),
+		boolArgument("dry_run", "Dry run", "Only report what would be removed. Enabled unless explicitly disabled.", true),
+	},
+	ReturnTypes: []*configv1.Field{
+		boolArgument("completed", "Completed", "Whether every erasure step succeeded.", false),
+	},
+}
+
+// erasureStep is an entry of the erasure report.
+type erasureStep struct {
+	name   string
+	status string
+	count  int
+	err    error
+}
+
+func (s erasureStep) report() map[string]interface{} {
+	report := map[string]interface{}{
+		"step":   s.name,
+		"status": s.status,
+		"count":  s.count,
+	}
+	if s.err != nil {
+		report["error"] = s.err.Error()
+	}
+	return report
+}
+
+// eraseUser erases the user for a right to be forgotten request. The steps stop at the first failure, the account
+// being deleted last it is still there to run the action again, and the report records what was already removed.
+func (a *customActions) eraseUser(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	userID := stringArg(args, "user_id")
+	if userID == "" {
+		return nil, nil, fmt.Errorf("user_id is required")
+	}
+	dryRun := boolArg(args, "dry_run", true)
+	if !dryRun && stringArg(args, "confirm") != userID {
+		return nil, nil, fmt.Errorf("confirm must be the ID of the user to erase")
+	}
+
+	dump, _, err := a.client.GetUserDump(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting data dump of user %s: %w", userID, err)
+	}
+
+	status := "removed"
+	if dryRun {
+		status = "would_be_removed"
+	}
+
+	var steps []erasureStep
+	failed := false
+	run := func(name string, count int, do func() error) {
+		if failed {
+			return
+		}
+		step := erasureStep{name: name, status: status, count: count}
+		if !dryRun {
+			if err := do(); err != nil {
+				l.Error("error erasing user", zap.String("user_id", userID), zap.String("step", name), zap.Error(err))
+				step.status = "failed"
+				step.err = err
+				failed = true
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	for _, kind := range erasedUserContents {
+		run(kind, dumpSectionLength(dump, client.UserDumpSections[kind]), func() error {
+			_, err := a.client.DeleteUserContent(ctx, userID, kind)
+			return err
+		})
+	}
+
+	switch {
+	case failed:
+	case dryRun:
+		steps = append(steps, erasureStep{name: "feedback_and_analytics", status: "would_be_anonymized"})
+	default:
+		anonymized, _, err := a.client.AnonymizeUser(ctx, userID)
+		if err != nil {
+			l.Error("error erasing user", zap.String("user_id", userID), zap.String("step", "feedback_and_analytics"), zap.Error(err))
+			steps = append(steps, erasureStep{name: "feedback_and_analytics", status: "failed", err: err})
+			failed = true
+			break
+		}
+		steps = append(steps,
+			erasureStep{name: "feedback", status: "anonymized", count: anonymized.Feedback},
+			erasureStep{name: "ratings", status: "anonymized", count: anonymized.Ratings},
+			erasureStep{name: "analytics_events", status: "anonymized", count: anonymized.AnalyticsEvents},
+		)
+	}
+
+	if a.revokeSessionsOnDelete {
+		run("sessions", 0, func() error {
+			_, _, err := revokeUserSessions(ctx, a.client, userID)
+			return err
+		})
+	}
+	run("account", 1, func() error {
+		_, err := a.client.DeleteUser(ctx, userID)
+		return err
+	})
+
+	var reports []interface{}
+	for _, step := range steps {
+		reports = append(reports, step.report())
+	}
+
+	l.Info("user erasure", zap.String("user_id", userID), zap.Bool("dry_run", dryRun), zap.Bool("failed", failed))
+
+	ret, err := structpb.NewStruct(map[string]interface{}{
+		"user_id":   userID,
+		"dry_run":   dryRun,
+		"completed": !dryRun && !failed,
+		"steps":     reports,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ret, nil, nil
+}
+
+// dumpSectionLength returns the number of items of a section of the user data dump.
+func dumpSectionLength(dump map[string]json.RawMessage, section string) int {
+	var items []json.RawMessage
+	if err := json.Unmarshal(dump[section], &items); err != nil {
+		return 0
+	}
+	return len(items)
+}
@@ -0,0 +1,110 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCustomActions_EraseUser(t *testing.T) {
+	ctx := context.Background()
+
+	dump := map[string]json.RawMessage{
+		"user":          json.RawMessage(`{"id":"user-1"}`),
+		"personalBooks": json.RawMessage(`[{"id":"book-1"},{"id":"book-2"}]`),
+		"bookmarks":     json.RawMessage(`[{"id":"bookmark-1"}]`),
+	}
+
+	newActions := func() (*customActions, *client.MockFluidTopicsClient) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("GetUserDump", mock.Anything, "user-1").Return(dump, annotations.Annotations{}, nil)
+		return newCustomActions(mockClient, newManualRolesUpdater(mockClient)), mockClient
+	}
+
+	stepsOf := func(res *structpb.Struct) map[string]map[string]interface{} {
+		steps := make(map[string]map[string]interface{})
+		for _, step := range res.Fields["steps"].GetListValue().AsSlice() {
+			report := step.(map[string]interface{})
+			steps[report["step"].(string)] = report
+		}
+		return steps
+	}
+
+	t.Run("Dry run only reports", func(t *testing.T) {
+		a, mockClient := newActions()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"user_id": "user-1"})
+		require.NoError(t, err)
+		res, _, err := a.eraseUser(ctx, args)
+		require.NoError(t, err)
+		require.True(t, res.Fields["dry_run"].GetBoolValue())
+		require.False(t, res.Fields["completed"].GetBoolValue())
+
+		steps := stepsOf(res)
+		require.EqualValues(t, 2, steps[client.UserContentPersonalBooks]["count"])
+		require.EqualValues(t, 1, steps[client.UserContentBookmarks]["count"])
+		require.Equal(t, "would_be_removed", steps["account"]["status"])
+		mockClient.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+		mockClient.AssertNotCalled(t, "DeleteUserContent", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires the confirmation", func(t *testing.T) {
+		a, mockClient := newActions()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"user_id": "user-1", "dry_run": false, "confirm": "user-2"})
+		require.NoError(t, err)
+		_, _, err = a.eraseUser(ctx, args)
+		require.Error(t, err)
+		mockClient.AssertNotCalled(t, "GetUserDump", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Erases the content, the feedback and the account", func(t *testing.T) {
+		a, mockClient := newActions()
+		for _, kind := range erasedUserContents {
+			mockClient.On("DeleteUserContent", mock.Anything, "user-1", kind).Return(annotations.Annotations{}, nil).Once()
+		}
+		mockClient.On("AnonymizeUser", mock.Anything, "user-1").
+			Return(client.UserAnonymization{Feedback: 3, Ratings: 1, AnalyticsEvents: 40}, annotations.Annotations{}, nil).Once()
+		mockClient.On("DeleteUser", mock.Anything, "user-1").Return(annotations.Annotations{}, nil).Once()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"user_id": "user-1", "dry_run": false, "confirm": "user-1"})
+		require.NoError(t, err)
+		res, _, err := a.eraseUser(ctx, args)
+		require.NoError(t, err)
+		require.True(t, res.Fields["completed"].GetBoolValue())
+
+		steps := stepsOf(res)
+		require.EqualValues(t, 3, steps["feedback"]["count"])
+		require.EqualValues(t, 40, steps["analytics_events"]["count"])
+		require.Equal(t, "removed", steps["account"]["status"])
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Keeps the account when a step fails", func(t *testing.T) {
+		a, mockClient := newActions()
+		for _, kind := range erasedUserContents {
+			mockClient.On("DeleteUserContent", mock.Anything, "user-1", kind).Return(annotations.Annotations{}, nil).Once()
+		}
+		mockClient.On("AnonymizeUser", mock.Anything, "user-1").
+			Return(client.UserAnonymization{}, annotations.Annotations{}, errors.New("unavailable")).Once()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"user_id": "user-1", "dry_run": false, "confirm": "user-1"})
+		require.NoError(t, err)
+		res, _, err := a.eraseUser(ctx, args)
+		require.NoError(t, err)
+		require.False(t, res.Fields["completed"].GetBoolValue())
+
+		steps := stepsOf(res)
+		require.Equal(t, "failed", steps["feedback_and_analytics"]["status"])
+		require.Equal(t, "unavailable", steps["feedback_and_analytics"]["error"])
+		require.NotContains(t, steps, "account")
+		mockClient.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+	})
+}
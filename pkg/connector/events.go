@@ -0,0 +1,304 @@
+package connector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultEventsPageSize = 100
+	defaultEventsLookback = 24 * time.Hour
+)
+
+var usageEventTypes = []string{
+	client.AnalyticsEventLogin,
+	client.AnalyticsEventDocumentDisplay,
+	client.AnalyticsEventSearch,
+	client.AnalyticsEventDocumentExport,
+}
+
+// eventFeedCursor is the position of the event feed in each of its streams, it is serialized as the stream cursor.
+type eventFeedCursor struct {
+	Usage  eventStreamPosition `json:"usage"`
+	Access eventStreamPosition `json:"access"`
+}
+
+// eventStreamPosition points after the last event returned from a stream. Events are read oldest first from
+// From, and the IDs of the events already returned for that exact date are kept to skip them on the next page.
+type eventStreamPosition struct {
+	From time.Time `json:"from"`
+	Seen []string  `json:"seen,omitempty"`
+}
+
+// skip reports whether the event was already returned, otherwise it moves the position after it.
+func (p *eventStreamPosition) skip(id string, date time.Time) bool {
+	if date.Before(p.From) || (date.Equal(p.From) && slices.Contains(p.Seen, id)) {
+		return true
+	}
+
+	if date.After(p.From) {
+		p.From = date
+		p.Seen = nil
+	}
+	p.Seen = append(p.Seen, id)
+
+	return false
+}
+
+type eventFeed struct {
+	client client.FluidTopicsClientInterface
+}
+
+// ListEvents returns the usage events read from the Fluid Topics analytics, and the grant and revoke events read
+// from the users history. Both streams are read from the same cursor and advance independently. Without usage the
+// analytics are not read and the usage stream stays where it is.
+func (e *eventFeed) ListEvents(
+	ctx context.Context,
+	earliestEvent *timestamppb.Timestamp,
+	pToken *pagination.StreamToken,
+	usage bool,
+) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	cursor, err := parseEventFeedCursor(earliestEvent, pToken)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	pageSize := pToken.Size
+	if pageSize <= 0 {
+		pageSize = defaultEventsPageSize
+	}
+
+	var usageEvents []*v2.Event
+	usageHasMore := false
+	annotation := annotations.Annotations{}
+	if usage {
+		usageEvents, usageHasMore, annotation, err = e.listUsageEvents(ctx, &cursor.Usage, pageSize)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	accessEvents, accessHasMore, accessAnnotation, err := e.listAccessEvents(ctx, &cursor.Access, pageSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	annotation.Merge(accessAnnotation...)
+
+	nextCursor, err := json.Marshal(cursor)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	events := slices.Concat(usageEvents, accessEvents)
+
+	return events, &pagination.StreamState{Cursor: string(nextCursor), HasMore: usageHasMore || accessHasMore}, annotation, nil
+}
+
+func (e *eventFeed) listUsageEvents(ctx context.Context, position *eventStreamPosition, pageSize int) ([]*v2.Event, bool, annotations.Annotations, error) {
+	res, annotation, err := e.client.ListAnalyticsEvents(ctx, client.AnalyticsEventsRequest{
+		StartDate:  position.From,
+		EventTypes: usageEventTypes,
+		// The events already seen at the start date are returned again, ask for them on top of the page.
+		Limit: pageSize + len(position.Seen),
+	})
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("error listing analytics events: %w", err)
+	}
+
+	var events []*v2.Event
+	for _, analyticsEvent := range res.Events {
+		eventID := analyticsEventId(analyticsEvent)
+		if position.skip(eventID, analyticsEvent.Date) {
+			continue
+		}
+
+		if event := parseIntoUsageEvent(eventID, analyticsEvent); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	return events, res.HasMore, annotation, nil
+}
+
+// listAccessEvents turns the changes of manual roles made by administrators into grant and revoke events,
+// so access given outside ConductorOne is visible before the next full sync.
+func (e *eventFeed) listAccessEvents(ctx context.Context, position *eventStreamPosition, pageSize int) ([]*v2.Event, bool, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	res, annotation, err := e.client.ListUserChanges(ctx, client.UserChangesRequest{
+		StartDate: position.From,
+		Limit:     pageSize + len(position.Seen),
+	})
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("error listing users history: %w", err)
+	}
+
+	var events []*v2.Event
+	for _, change := range res.Changes {
+		if position.skip(change.Id, change.Date) {
+			continue
+		}
+
+		var newGrant func(name string, principal *v2.Resource) *v2.Grant
+		switch change.Field {
+		case client.UserChangeManualRoles:
+			newGrant = func(roleName string, principal *v2.Resource) *v2.Grant {
+				return newRoleGrant(manualRole, roleName, principal)
+			}
+		case client.UserChangeGroups:
+			newGrant = newGroupMemberGrant
+		default:
+			l.Debug("skipping user change", zap.String("change_id", change.Id), zap.String("field", change.Field))
+			continue
+		}
+
+		events = append(events, parseIntoAccessEvents(change, newGrant)...)
+	}
+
+	return events, res.HasMore, annotation, nil
+}
+
+func parseEventFeedCursor(earliestEvent *timestamppb.Timestamp, pToken *pagination.StreamToken) (*eventFeedCursor, error) {
+	cursor := &eventFeedCursor{}
+
+	if pToken.Cursor != "" {
+		if err := json.Unmarshal([]byte(pToken.Cursor), cursor); err != nil {
+			return nil, fmt.Errorf("invalid event feed cursor: %w", err)
+		}
+		return cursor, nil
+	}
+
+	from := time.Now().Add(-defaultEventsLookback)
+	if earliestEvent != nil {
+		from = earliestEvent.AsTime()
+	}
+	cursor.Usage.From = from
+	cursor.Access.From = from
+
+	return cursor, nil
+}
+
+// analyticsEventId returns the ID of the event, events without one get an ID derived from their content.
+func analyticsEventId(event client.AnalyticsEvent) string {
+	if event.Id != "" {
+		return event.Id
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s|%s|%s|%s|%s", event.Type, event.Date.Format(time.RFC3339Nano), event.UserId, event.Document.Id, event.Query)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// parseIntoUsageEvent returns nil for anonymous events, since there is no user to attribute them to.
+func parseIntoUsageEvent(eventID string, event client.AnalyticsEvent) *v2.Event {
+	if event.UserId == "" {
+		return nil
+	}
+
+	actor := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: userResourceType.Id,
+			Resource:     event.UserId,
+		},
+		DisplayName: event.UserName,
+	}
+
+	var target *v2.Resource
+	switch event.Type {
+	case client.AnalyticsEventDocumentDisplay, client.AnalyticsEventDocumentExport:
+		description := fmt.Sprintf("Read %s", event.Document.Title)
+		if event.Type == client.AnalyticsEventDocumentExport {
+			description = fmt.Sprintf("Exported %s to %s", event.Document.Title, event.Format)
+		}
+		target = &v2.Resource{
+			Id: &v2.ResourceId{
+				ResourceType: documentResourceType.Id,
+				Resource:     event.Document.Id,
+			},
+			DisplayName: event.Document.Title,
+			Description: description,
+		}
+	case client.AnalyticsEventSearch:
+		target = &v2.Resource{
+			Id: &v2.ResourceId{
+				ResourceType: searchResourceType.Id,
+				Resource:     event.Query,
+			},
+			DisplayName: event.Query,
+			Description: fmt.Sprintf("Searched for %s", event.Query),
+		}
+	default:
+		target = actor
+	}
+
+	return &v2.Event{
+		Id:         eventID,
+		OccurredAt: timestamppb.New(event.Date),
+		Event: &v2.Event_UsageEvent{
+			UsageEvent: &v2.UsageEvent{
+				TargetResource: target,
+				ActorResource:  actor,
+			},
+		},
+	}
+}
+
+// parseIntoAccessEvents returns a grant event for every manual role or group added by the change, and a revoke event
+// for every one removed, newGrant building the same grants as the sync.
+func parseIntoAccessEvents(change client.UserChange, newGrant func(name string, principal *v2.Resource) *v2.Grant) []*v2.Event {
+	var events []*v2.Event
+
+	principal := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: userResourceType.Id,
+			Resource:     change.UserId,
+		},
+	}
+
+	for _, name := range change.Added {
+		events = append(events, &v2.Event{
+			Id:         fmt.Sprintf("%s:grant:%s", change.Id, name),
+			OccurredAt: timestamppb.New(change.Date),
+			Event: &v2.Event_GrantEvent{
+				GrantEvent: &v2.GrantEvent{
+					Grant: newGrant(name, principal),
+				},
+			},
+		})
+	}
+
+	for _, name := range change.Removed {
+		removed := newGrant(name, principal)
+		events = append(events, &v2.Event{
+			Id:         fmt.Sprintf("%s:revoke:%s", change.Id, name),
+			OccurredAt: timestamppb.New(change.Date),
+			Event: &v2.Event_RevokeEvent{
+				RevokeEvent: &v2.RevokeEvent{
+					Entitlement: removed.Entitlement,
+					Principal:   removed.Principal,
+				},
+			},
+		})
+	}
+
+	return events
+}
+
+func newEventFeed(c client.FluidTopicsClientInterface) *eventFeed {
+	return &eventFeed{
+		client: c,
+	}
+}
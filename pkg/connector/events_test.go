@@ -0,0 +1,128 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func analyticsEvent(id string, eventType string, date time.Time, userID string) client.AnalyticsEvent {
+	event := client.AnalyticsEvent{
+		Id:     id,
+		Type:   eventType,
+		Date:   date,
+		UserId: userID,
+	}
+	event.Document.Id = "doc-1"
+	event.Document.Title = "Install guide"
+	return event
+}
+
+func TestEventFeed_UsageEvents(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	later := start.Add(time.Minute)
+
+	t.Run("ListEvents starts from the earliest event and dedupes across pages", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		feed := newEventFeed(mockClient)
+
+		mockClient.On("ListUserChanges", mock.Anything, mock.Anything).
+			Return(client.UserChangesResponse{}, annotations.Annotations{}, nil)
+
+		mockClient.On("ListAnalyticsEvents", mock.Anything, client.AnalyticsEventsRequest{
+			StartDate:  start,
+			EventTypes: usageEventTypes,
+			Limit:      2,
+		}).Return(client.AnalyticsEventsResponse{
+			Events: []client.AnalyticsEvent{
+				analyticsEvent("e1", client.AnalyticsEventLogin, start, "u1"),
+				analyticsEvent("e2", client.AnalyticsEventDocumentDisplay, later, "u1"),
+			},
+			HasMore: true,
+		}, annotations.Annotations{}, nil).Once()
+
+		events, state, _, err := feed.ListEvents(ctx, timestamppb.New(start), &pagination.StreamToken{Size: 2}, true)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.True(t, state.HasMore)
+		require.Equal(t, "e1", events[0].Id)
+		require.Equal(t, "u1", events[0].GetUsageEvent().ActorResource.Id.Resource)
+		require.Equal(t, documentResourceType.Id, events[1].GetUsageEvent().TargetResource.Id.ResourceType)
+
+		// The next page starts at the date of the last event, which is returned again and must be skipped.
+		mockClient.On("ListAnalyticsEvents", mock.Anything, client.AnalyticsEventsRequest{
+			StartDate:  later,
+			EventTypes: usageEventTypes,
+			Limit:      3,
+		}).Return(client.AnalyticsEventsResponse{
+			Events: []client.AnalyticsEvent{
+				analyticsEvent("e2", client.AnalyticsEventDocumentDisplay, later, "u1"),
+				analyticsEvent("e3", client.AnalyticsEventSearch, later, "u2"),
+				analyticsEvent("e4", client.AnalyticsEventDocumentExport, later, ""),
+			},
+		}, annotations.Annotations{}, nil).Once()
+
+		events, state, _, err = feed.ListEvents(ctx, timestamppb.New(start), &pagination.StreamToken{Size: 2, Cursor: state.Cursor}, true)
+		require.NoError(t, err)
+		require.False(t, state.HasMore)
+		require.Len(t, events, 1)
+		require.Equal(t, "e3", events[0].Id)
+		require.Equal(t, searchResourceType.Id, events[0].GetUsageEvent().TargetResource.Id.ResourceType)
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("ListEvents returns grant and revoke events from the roles and groups history", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		feed := newEventFeed(mockClient)
+
+		mockClient.On("ListAnalyticsEvents", mock.Anything, mock.Anything).
+			Return(client.AnalyticsEventsResponse{}, annotations.Annotations{}, nil)
+		mockClient.On("ListUserChanges", mock.Anything, client.UserChangesRequest{StartDate: start, Limit: 10}).
+			Return(client.UserChangesResponse{
+				Changes: []client.UserChange{
+					{Id: "c1", Date: later, UserId: "u1", Field: client.UserChangeManualRoles, Added: []string{"ADMIN"}, Removed: []string{"PRINT_USER"}},
+					{Id: "c2", Date: later, UserId: "u1", Field: client.UserChangeGroups, Added: []string{"writers"}},
+				},
+			}, annotations.Annotations{}, nil).Once()
+
+		events, _, _, err := feed.ListEvents(ctx, timestamppb.New(start), &pagination.StreamToken{Size: 10}, true)
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+
+		grantEvent := events[0].GetGrantEvent()
+		require.NotNil(t, grantEvent)
+		require.Equal(t, "Role:manual:ADMIN:assigned", grantEvent.Grant.Entitlement.Id)
+		require.Equal(t, "u1", grantEvent.Grant.Principal.Id.Resource)
+
+		revokeEvent := events[1].GetRevokeEvent()
+		require.NotNil(t, revokeEvent)
+		require.Equal(t, "Role:manual:PRINT_USER:assigned", revokeEvent.Entitlement.Id)
+		require.Equal(t, "u1", revokeEvent.Principal.Id.Resource)
+
+		groupEvent := events[2].GetGrantEvent()
+		require.NotNil(t, groupEvent)
+		require.Equal(t, "group:writers:member", groupEvent.Grant.Entitlement.Id)
+		require.Equal(t, "u1", groupEvent.Grant.Principal.Id.Resource)
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Events without ID get a stable one", func(t *testing.T) {
+		event := analyticsEvent("", client.AnalyticsEventDocumentDisplay, start, "u1")
+		require.Equal(t, analyticsEventId(event), analyticsEventId(event))
+		require.NotEmpty(t, analyticsEventId(event))
+	})
+
+	t.Run("Anonymous events are not usage events", func(t *testing.T) {
+		require.Nil(t, parseIntoUsageEvent("e1", analyticsEvent("e1", client.AnalyticsEventLogin, start, "")))
+	})
+}
@@ -0,0 +1,30 @@
+//go:build ignore
+
+// gen_capabilities writes the capabilities manifest of the connector to the file given as argument.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/connector"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: go run gen_capabilities.go <output>")
+		os.Exit(1)
+	}
+
+	manifest, err := connector.CapabilitiesManifest(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(os.Args[1], append(manifest, '\n'), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,146 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const groupMemberEntitlement = "member"
+
+// groupBuilder syncs the groups of the users, personal books and collections can be shared with them.
+// Fluid Topics does not list the groups, they are gathered from the manual and authentication groups of every user.
+type groupBuilder struct {
+	resourceType *v2.ResourceType
+	client       client.FluidTopicsClientInterface
+	directory    *userDirectory
+
+	mu sync.Mutex
+	// members are the IDs of the users of each group, by group name, from the last listing.
+	members map[string][]string
+}
+
+func (g *groupBuilder) ResourceType(ctx context.Context) *v2.ResourceType { return groupResourceType }
+
+// List returns every group at least one user belongs to.
+func (g *groupBuilder) List(ctx context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	users, err := g.directory.users(ctx, directoryConsumerGroups)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	members := make(map[string][]string)
+	for _, user := range users {
+		userGroups, _, err := g.client.GetGroupsByUserID(ctx, user.Id)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("error getting user groups %s: %w", user.Id, err)
+		}
+		for _, name := range userGroups.ManualGroups {
+			members[name] = append(members[name], user.Id)
+		}
+		for _, name := range userGroups.AuthenticationGroups {
+			if !slices.Contains(members[name], user.Id) {
+				members[name] = append(members[name], user.Id)
+			}
+		}
+	}
+
+	g.mu.Lock()
+	g.members = members
+	g.mu.Unlock()
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	resources := make([]*v2.Resource, 0, len(names))
+	for _, name := range names {
+		groupResource, err := parseIntoGroupResource(name, len(members[name]))
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, groupResource)
+	}
+
+	return resources, "", nil, nil
+}
+
+// Entitlements returns the member entitlement of the group.
+func (g *groupBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			groupMemberEntitlement,
+			entitlement.WithGrantableTo(userResourceType),
+			entitlement.WithDisplayName(fmt.Sprintf("%s member", resource.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Member of the group %s", resource.DisplayName)),
+		),
+	}, "", nil, nil
+}
+
+// Grants returns a member grant for every user of the group, from the listing of the groups.
+func (g *groupBuilder) Grants(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	g.mu.Lock()
+	members := g.members[resource.Id.Resource]
+	g.mu.Unlock()
+
+	grants := make([]*v2.Grant, 0, len(members))
+	for _, userID := range members {
+		grants = append(grants, grant.NewGrant(resource, groupMemberEntitlement, &v2.ResourceId{
+			ResourceType: userResourceType.Id,
+			Resource:     userID,
+		}))
+	}
+
+	return grants, "", nil, nil
+}
+
+// newGroupMemberGrant returns the member grant of the group to the user, built the same way as during the sync.
+func newGroupMemberGrant(groupName string, principal *v2.Resource) *v2.Grant {
+	groupResource := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: groupResourceType.Id,
+			Resource:     groupName,
+		},
+		DisplayName: groupName,
+	}
+
+	return grant.NewGrant(groupResource, groupMemberEntitlement, principal)
+}
+
+func parseIntoGroupResource(name string, memberCount int) (*v2.Resource, error) {
+	ret, err := rs.NewGroupResource(
+		name,
+		groupResourceType,
+		name,
+		[]rs.GroupTraitOption{rs.WithGroupProfile(map[string]interface{}{
+			"member_count": memberCount,
+		})},
+		rs.WithDescription(fmt.Sprintf("Group of %d users", memberCount)),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func newGroupBuilder(c client.FluidTopicsClientInterface, directory *userDirectory) *groupBuilder {
+	return &groupBuilder{
+		resourceType: groupResourceType,
+		client:       c,
+		directory:    directory,
+	}
+}
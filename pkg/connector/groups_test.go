@@ -0,0 +1,57 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupBuilder(t *testing.T) {
+	ctx := context.Background()
+
+	mockClient := &client.MockFluidTopicsClient{}
+	mockClient.On("ListUsers", mock.Anything).
+		Return([]client.User{{Id: "writer"}, {Id: "reader"}}, "", annotations.Annotations{}, nil).Once()
+	for _, userID := range []string{"writer", "reader"} {
+		mockClient.On("GetUserDetails", mock.Anything, userID).Return(client.User{Id: userID}, annotations.Annotations{}, nil).Once()
+	}
+	mockClient.On("GetGroupsByUserID", mock.Anything, "writer").
+		Return(client.UserGroups{ManualGroups: []string{"partners"}, AuthenticationGroups: []string{"partners", "staff"}}, annotations.Annotations{}, nil).Once()
+	mockClient.On("GetGroupsByUserID", mock.Anything, "reader").
+		Return(client.UserGroups{AuthenticationGroups: []string{"partners"}}, annotations.Annotations{}, nil).Once()
+
+	gb := newGroupBuilder(mockClient, newUserDirectory(mockClient, nil))
+
+	resources, _, _, err := gb.List(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	require.Equal(t, "partners", resources[0].Id.Resource)
+	require.Equal(t, "staff", resources[1].Id.Resource)
+
+	groupTrait, err := rs.GetGroupTrait(resources[0])
+	require.NoError(t, err)
+	require.EqualValues(t, 2, groupTrait.GetProfile().AsMap()["member_count"])
+
+	entitlements, _, _, err := gb.Entitlements(ctx, resources[0], nil)
+	require.NoError(t, err)
+	require.Len(t, entitlements, 1)
+	require.Equal(t, "group:partners:member", entitlements[0].Id)
+
+	// A user in both the manual and the authentication groups is a member once.
+	grants, _, _, err := gb.Grants(ctx, resources[0], nil)
+	require.NoError(t, err)
+	require.Len(t, grants, 2)
+	require.Equal(t, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "writer"}, grants[0].Principal.Id)
+	require.Equal(t, "reader", grants[1].Principal.Id.Resource)
+
+	grants, _, _, err = gb.Grants(ctx, resources[1], nil)
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	mockClient.AssertExpectations(t)
+}
@@ -3,10 +3,13 @@ package connector
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/crypto"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
 )
 
 const permissionName = "assigned"
@@ -22,6 +25,14 @@ func getRoleDescription(roleName string) string {
 	return ""
 }
 
+// allRoleNames returns the names of every role known by the connector, sorted.
+func allRoleNames() []string {
+	names := slices.Collect(maps.Keys(roles))
+	names = append(names, slices.Collect(maps.Keys(adminRoles))...)
+	slices.Sort(names)
+	return names
+}
+
 // parseEntitlementId is responsible for cutting the id of the entitlement to know its name and type.
 func parseEntitlementId(entitlementId string) (string, string, error) {
 	parts := strings.Split(entitlementId, ":")
@@ -31,6 +42,21 @@ func parseEntitlementId(entitlementId string) (string, string, error) {
 	return parts[1], parts[2], nil
 }
 
+// newRoleGrant builds the grant of a role to a principal.
+// It is shared by the sync and the provisioning so both produce the same grant IDs.
+func newRoleGrant(roleType string, roleName string, principal *v2.Resource) *v2.Grant {
+	roleResource := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: roleResourceType.Id,
+			Resource:     fmt.Sprintf("%s:%s", roleType, roleName),
+		},
+		DisplayName: fmt.Sprintf("%s:%s", roleType, roleName),
+		Description: getRoleDescription(roleName),
+	}
+
+	return grant.NewGrant(roleResource, permissionName, principal)
+}
+
 // generateCredentials if the credential option is "Random Password", it returns a randomly generated password.
 func generateCredentials(credentialOptions *v2.CredentialOptions) (string, error) {
 	if credentialOptions.GetRandomPassword() == nil {
@@ -0,0 +1,211 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var inactiveUsersArguments = []*configv1.Field{
+	intArgument("inactive_days", "Inactive days", "Users without activity for at least this number of days are inactive.", true),
+	stringArgument("realm", "Realm", "Only consider the users authenticated through this realm.", false),
+	stringArgument("role", "Role", "Only consider the users holding this role, whatever its type.", false),
+}
+
+var findInactiveUsersSchema = &v2.BatonActionSchema{
+	Name:        "find_inactive_users",
+	DisplayName: "Find inactive users",
+	Description: "Lists the users whose last activity is older than the threshold.",
+	Arguments:   inactiveUsersArguments,
+	ReturnTypes: []*configv1.Field{
+		intArgument("count", "Count", "Number of inactive users.", true),
+	},
+}
+
+var stripInactiveRolesSchema = &v2.BatonActionSchema{
+	Name:        "strip_inactive_roles",
+	DisplayName: "Strip roles of inactive users",
+	Description: "Removes the manual roles of the users whose last activity is older than the threshold. " +
+		"When a role is given only this role is removed.",
+	Arguments: append(slices.Clone(inactiveUsersArguments),
+		boolArgument("dry_run", "Dry run", "Only report the roles that would be removed. Enabled unless explicitly disabled.", true),
+	),
+	ReturnTypes: []*configv1.Field{
+		intArgument("count", "Count", "Number of users whose roles were, or would be, removed.", true),
+	},
+}
+
+type inactiveUser struct {
+	user  client.User
+	roles client.UserRoles
+}
+
+func (u inactiveUser) report() map[string]interface{} {
+	lastActivity := ""
+	if !u.user.LastLoginDate.IsZero() {
+		lastActivity = u.user.LastLoginDate.Format(time.RFC3339)
+	}
+
+	var realms []string
+	for _, identifier := range u.user.AuthenticationIdentifiers {
+		realms = append(realms, identifier.Realm)
+	}
+
+	return map[string]interface{}{
+		"user_id":              u.user.Id,
+		"user_name":            u.user.DisplayName,
+		"email":                u.user.Email,
+		"realms":               toList(realms),
+		"last_activity":        lastActivity,
+		"manual_roles":         toList(u.roles.ManualRoles),
+		"authentication_roles": toList(u.roles.AuthenticationRoles),
+		"default_roles":        toList(u.roles.DefaultRoles),
+	}
+}
+
+func (a *customActions) findInactiveUsers(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	users, err := a.listInactiveUsers(ctx, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reports []map[string]interface{}
+	for _, user := range users {
+		reports = append(reports, user.report())
+	}
+
+	ret, err := structpb.NewStruct(map[string]interface{}{
+		"count": len(users),
+		"users": toList(reports),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ret, nil, nil
+}
+
+// stripInactiveRoles removes the manual roles of the inactive users through the same update path as role revocations.
+// A failure on a user does not stop the action, it is reported with the other results.
+func (a *customActions) stripInactiveRoles(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	dryRun := boolArg(args, "dry_run", true)
+	role := stringArg(args, "role")
+
+	users, err := a.listInactiveUsers(ctx, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reports []interface{}
+	var failures []interface{}
+	for _, user := range users {
+		toRemove := user.roles.ManualRoles
+		if role != "" {
+			toRemove = nil
+			if slices.Contains(user.roles.ManualRoles, role) {
+				toRemove = []string{role}
+			}
+		}
+		if len(toRemove) == 0 {
+			continue
+		}
+
+		report := user.report()
+		report["removed_roles"] = toList(toRemove)
+
+		if !dryRun {
+			_, _, err := a.manualRoles.update(ctx, user.user.Id, nil, toRemove)
+			if err != nil {
+				l.Error("error removing roles of inactive user", zap.String("user_id", user.user.Id), zap.Error(err))
+				failures = append(failures, map[string]interface{}{
+					"user_id": user.user.Id,
+					"error":   err.Error(),
+				})
+				continue
+			}
+		}
+
+		reports = append(reports, report)
+	}
+
+	ret, err := structpb.NewStruct(map[string]interface{}{
+		"dry_run":  dryRun,
+		"count":    len(reports),
+		"users":    reports,
+		"failures": failures,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ret, nil, nil
+}
+
+// listInactiveUsers returns the users without activity since the threshold, users who never had any activity are
+// compared by their creation date.
+func (a *customActions) listInactiveUsers(ctx context.Context, args *structpb.Struct) ([]inactiveUser, error) {
+	days, ok := intArg(args, "inactive_days")
+	if !ok || days <= 0 {
+		return nil, fmt.Errorf("inactive_days must be a positive number of days")
+	}
+	realm := stringArg(args, "realm")
+	role := stringArg(args, "role")
+	threshold := a.now().AddDate(0, 0, -days)
+
+	users, _, _, err := a.client.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var inactiveUsers []inactiveUser
+	for _, listedUser := range users {
+		user, _, err := a.client.GetUserDetails(ctx, listedUser.Id)
+		if err != nil {
+			return nil, fmt.Errorf("error getting user details %s: %w", listedUser.Id, err)
+		}
+
+		lastActivity := user.LastLoginDate
+		if lastActivity.IsZero() {
+			lastActivity = user.CreationDate
+		}
+		if lastActivity.After(threshold) {
+			continue
+		}
+
+		if realm != "" && !slices.ContainsFunc(user.AuthenticationIdentifiers, func(identifier client.AuthenticationIdentifiers) bool {
+			return identifier.Realm == realm
+		}) {
+			continue
+		}
+
+		userRoles, _, err := a.client.GetRolesByUserID(ctx, user.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if role != "" &&
+			!slices.Contains(userRoles.ManualRoles, role) &&
+			!slices.Contains(userRoles.AuthenticationRoles, role) &&
+			!slices.Contains(userRoles.DefaultRoles, role) {
+			continue
+		}
+
+		inactiveUsers = append(inactiveUsers, inactiveUser{
+			user:  user,
+			roles: userRoles,
+		})
+	}
+
+	return inactiveUsers, nil
+}
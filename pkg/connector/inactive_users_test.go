@@ -0,0 +1,106 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCustomActions_InactiveUsers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	activeUser := client.User{Id: "active", LastLoginDate: now.AddDate(0, 0, -5)}
+	inactiveUser := client.User{
+		Id:            "inactive",
+		Email:         "old@x.com",
+		LastLoginDate: now.AddDate(0, 0, -200),
+		AuthenticationIdentifiers: []client.AuthenticationIdentifiers{
+			{Identifier: "old@x.com", Realm: "okta"},
+		},
+	}
+	neverActiveUser := client.User{Id: "never", CreationDate: now.AddDate(-1, 0, 0)}
+
+	newActions := func() (*customActions, *client.MockFluidTopicsClient) {
+		mockClient := &client.MockFluidTopicsClient{}
+		a := newCustomActions(mockClient, newManualRolesUpdater(mockClient))
+		a.now = func() time.Time { return now }
+
+		mockClient.On("ListUsers", mock.Anything).
+			Return([]client.User{activeUser, inactiveUser, neverActiveUser}, "", annotations.Annotations{}, nil)
+		for _, user := range []client.User{activeUser, inactiveUser, neverActiveUser} {
+			mockClient.On("GetUserDetails", mock.Anything, user.Id).Return(user, annotations.Annotations{}, nil)
+		}
+		mockClient.On("GetRolesByUserID", mock.Anything, "never").
+			Return(client.UserRoles{DefaultRoles: []string{"PRINT_USER"}}, annotations.Annotations{}, nil)
+
+		return a, mockClient
+	}
+
+	t.Run("find_inactive_users filters by threshold, realm and role", func(t *testing.T) {
+		a, mockClient := newActions()
+		mockClient.On("GetRolesByUserID", mock.Anything, "inactive").
+			Return(client.UserRoles{ManualRoles: []string{"ADMIN"}}, annotations.Annotations{}, nil)
+
+		args, err := structpb.NewStruct(map[string]interface{}{"inactive_days": 90})
+		require.NoError(t, err)
+		res, _, err := a.findInactiveUsers(ctx, args)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, res.Fields["count"].GetNumberValue())
+
+		args, err = structpb.NewStruct(map[string]interface{}{"inactive_days": 90, "realm": "okta", "role": "ADMIN"})
+		require.NoError(t, err)
+		res, _, err = a.findInactiveUsers(ctx, args)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, res.Fields["count"].GetNumberValue())
+		user := res.Fields["users"].GetListValue().Values[0].GetStructValue()
+		require.Equal(t, "inactive", user.Fields["user_id"].GetStringValue())
+	})
+
+	t.Run("find_inactive_users requires a threshold", func(t *testing.T) {
+		a, _ := newActions()
+		_, _, err := a.findInactiveUsers(ctx, &structpb.Struct{})
+		require.Error(t, err)
+	})
+
+	t.Run("strip_inactive_roles only reports in dry run", func(t *testing.T) {
+		a, mockClient := newActions()
+		mockClient.On("GetRolesByUserID", mock.Anything, "inactive").
+			Return(client.UserRoles{ManualRoles: []string{"ADMIN", "PRINT_USER"}}, annotations.Annotations{}, nil)
+
+		args, err := structpb.NewStruct(map[string]interface{}{"inactive_days": 90})
+		require.NoError(t, err)
+		res, _, err := a.stripInactiveRoles(ctx, args)
+		require.NoError(t, err)
+		require.True(t, res.Fields["dry_run"].GetBoolValue())
+		require.EqualValues(t, 1, res.Fields["count"].GetNumberValue())
+
+		mockClient.AssertNotCalled(t, "UpdateUserManualRoles", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("strip_inactive_roles removes the filtered role", func(t *testing.T) {
+		a, mockClient := newActions()
+		mockClient.On("GetRolesByUserID", mock.Anything, "inactive").
+			Return(client.UserRoles{ManualRoles: []string{"ADMIN", "PRINT_USER"}}, annotations.Annotations{}, nil).Twice()
+		mockClient.On("UpdateUserManualRoles", mock.Anything, "inactive", []string{"PRINT_USER"}).
+			Return(annotations.Annotations{}, nil).Once()
+		mockClient.On("GetRolesByUserID", mock.Anything, "inactive").
+			Return(client.UserRoles{ManualRoles: []string{"PRINT_USER"}}, annotations.Annotations{}, nil).Once()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"inactive_days": 90, "role": "ADMIN", "dry_run": false})
+		require.NoError(t, err)
+		res, _, err := a.stripInactiveRoles(ctx, args)
+		require.NoError(t, err)
+		require.False(t, res.Fields["dry_run"].GetBoolValue())
+		require.EqualValues(t, 1, res.Fields["count"].GetNumberValue())
+		require.Empty(t, res.Fields["failures"].GetListValue().GetValues())
+
+		mockClient.AssertExpectations(t)
+	})
+}
@@ -42,7 +42,7 @@ func initClient(t *testing.T) *client.FluidTopicsClient {
 func TestUserBuilderList(t *testing.T) {
 	c := initClient(t)
 
-	u := newUserBuilder(c)
+	u := newUserBuilder(c, false, nil, nil)
 	res, _, _, err := u.List(ctx, parentResourceID, pToken)
 	assert.Nil(t, err)
 	assert.NotNil(t, res)
@@ -54,7 +54,7 @@ func TestUserBuilderList(t *testing.T) {
 func TestRoleBuilderList(t *testing.T) {
 	c := initClient(t)
 
-	r := newRoleBuilder(c)
+	r := newRoleBuilder(c, newManualRolesUpdater(c))
 
 	res, _, _, err := r.List(ctx, parentResourceID, pToken)
 	assert.Nil(t, err)
@@ -0,0 +1,195 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// keyCapability is something the connector can only do when its API key has one of the roles.
+type keyCapability struct {
+	name string
+	// disables describes what the connector does not do without the capability.
+	disables string
+	roles    []string
+}
+
+var (
+	capabilitySyncUsers = keyCapability{
+		name:     "sync_users",
+		disables: "users, their roles and their personal content are not synced",
+		roles:    []string{"ADMIN", "USERS_ADMIN"},
+	}
+	capabilitySyncGroups = keyCapability{
+		name:     "sync_groups",
+		disables: "realms and the mapping rules granting roles to their groups are not synced",
+		roles:    []string{"ADMIN"},
+	}
+	capabilityManageRoles = keyCapability{
+		name:     "manage_roles",
+		disables: "manual roles cannot be granted nor revoked, realm mapping rules cannot be changed, strip_inactive_roles and merge_users are disabled",
+		roles:    []string{"ADMIN"},
+	}
+	capabilityManageUsers = keyCapability{
+		name:     "manage_users",
+		disables: "accounts cannot be created, content shares and saved search alerts cannot be revoked, erase_user, transfer_user_content, revoke_sessions and merge_users are disabled",
+		roles:    []string{"ADMIN"},
+	}
+	// No feature of the connector manages API keys yet, the capability is reported for least-privilege reviews.
+	capabilityManageApiKeys = keyCapability{
+		name:     "manage_api_keys",
+		disables: "API keys cannot be managed",
+		roles:    []string{"ADMIN"},
+	}
+	capabilityReadAnalytics = keyCapability{
+		name:     "read_analytics",
+		disables: "user activity metrics are not added to the user profiles, usage events are not streamed",
+		roles:    []string{"ADMIN", "ANALYTICS_USER"},
+	}
+)
+
+var keyCapabilitiesList = []keyCapability{
+	capabilitySyncUsers,
+	capabilitySyncGroups,
+	capabilityManageRoles,
+	capabilityManageUsers,
+	capabilityManageApiKeys,
+	capabilityReadAnalytics,
+}
+
+// keyCapabilities are the capabilities of the API key, by name.
+type keyCapabilities map[string]bool
+
+// capabilitiesOf returns the capabilities of an API key with the roles.
+func capabilitiesOf(roles []string) keyCapabilities {
+	capabilities := make(keyCapabilities, len(keyCapabilitiesList))
+	for _, capability := range keyCapabilitiesList {
+		capabilities[capability.name] = slices.ContainsFunc(capability.roles, func(role string) bool {
+			return slices.Contains(roles, role)
+		})
+	}
+	return capabilities
+}
+
+// allCapabilities is assumed when the roles of the API key cannot be read, Validate reports why.
+func allCapabilities() keyCapabilities {
+	return capabilitiesOf([]string{"ADMIN"})
+}
+
+func (k keyCapabilities) has(capability keyCapability) bool {
+	return k[capability.name]
+}
+
+func (k keyCapabilities) hasAll(capabilities []keyCapability) bool {
+	for _, capability := range capabilities {
+		if !k.has(capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// missing returns the capabilities the API key does not have.
+func (k keyCapabilities) missing() []keyCapability {
+	var missing []keyCapability
+	for _, capability := range keyCapabilitiesList {
+		if !k.has(capability) {
+			missing = append(missing, capability)
+		}
+	}
+	return missing
+}
+
+// keyCapabilities returns the capabilities of the API key, from the roles of its session. They are read again until
+// a read succeeds.
+func (d *Connector) keyCapabilities(ctx context.Context) (keyCapabilities, error) {
+	d.capabilitiesMtx.Lock()
+	defer d.capabilitiesMtx.Unlock()
+
+	if d.capabilities != nil {
+		return d.capabilities, nil
+	}
+
+	info, _, err := d.client.GetAuthenticationInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch current user roles: %w", err)
+	}
+	d.capabilities = capabilitiesOf(info.Profile.Roles)
+
+	return d.capabilities, nil
+}
+
+// keyCapabilitiesOrAll returns the capabilities of the API key, or all of them when its roles cannot be read.
+func (d *Connector) keyCapabilitiesOrAll(ctx context.Context) keyCapabilities {
+	capabilities, err := d.keyCapabilities(ctx)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("could not read the API key capabilities, assuming all of them", zap.Error(err))
+		return allCapabilities()
+	}
+	return capabilities
+}
+
+// syncOnly exposes the sync of a resource syncer and hides its provisioning, so the SDK does not register it.
+type syncOnly struct {
+	connectorbuilder.ResourceSyncer
+}
+
+// resourceSyncers returns the syncers the API key capabilities allow, without the provisioning it does not allow.
+// In read-only mode none of them provisions.
+func (d *Connector) resourceSyncers(ctx context.Context, capabilities keyCapabilities) []connectorbuilder.ResourceSyncer {
+	l := ctxzap.Extract(ctx)
+
+	activityMetrics := d.userActivityMetrics
+	if activityMetrics && !capabilities.has(capabilityReadAnalytics) {
+		l.Warn("user activity metrics disabled, the API key cannot read the analytics")
+		activityMetrics = false
+	}
+
+	directory := newUserDirectory(d.client, d.syncState)
+	var users connectorbuilder.ResourceSyncer = newUserBuilder(d.client, activityMetrics, directory, d.syncMetrics)
+	var roles connectorbuilder.ResourceSyncer = newRoleBuilder(d.client, d.manualRoles)
+	var realms connectorbuilder.ResourceSyncer = newRealmBuilder(d.client, d.dryRun)
+	var personalBooks connectorbuilder.ResourceSyncer = newPersonalBookBuilder(d.client)
+	var collections connectorbuilder.ResourceSyncer = newCollectionBuilder(d.client)
+	var savedSearches connectorbuilder.ResourceSyncer = newSavedSearchBuilder(d.client)
+
+	if d.readOnly || !capabilities.has(capabilityManageUsers) {
+		users = syncOnly{users}
+		personalBooks = syncOnly{personalBooks}
+		collections = syncOnly{collections}
+		savedSearches = syncOnly{savedSearches}
+	}
+	if d.readOnly || !capabilities.has(capabilityManageRoles) {
+		roles = syncOnly{roles}
+		realms = syncOnly{realms}
+	}
+
+	syncers := []connectorbuilder.ResourceSyncer{users, roles}
+	if capabilities.has(capabilitySyncGroups) {
+		syncers = append(syncers, realms, newMappingRuleBuilder(d.client))
+	}
+
+	return append(syncers,
+		newTenantBuilder(d.client, directory, d.domain),
+		newGroupBuilder(d.client, directory),
+		personalBooks,
+		collections,
+		savedSearches,
+	)
+}
+
+// logMissingCapabilities logs every capability the API key lacks, with what it disables and the roles granting it.
+func logMissingCapabilities(ctx context.Context, capabilities keyCapabilities) {
+	l := ctxzap.Extract(ctx)
+	for _, capability := range capabilities.missing() {
+		l.Warn("API key capability unavailable",
+			zap.String("capability", capability.name),
+			zap.String("impact", capability.disables),
+			zap.Strings("required_roles_any_of", capability.roles),
+		)
+	}
+}
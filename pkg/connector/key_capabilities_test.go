@@ -0,0 +1,143 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func authenticationInfo(roles ...string) client.AuthenticationInfo {
+	var info client.AuthenticationInfo
+	info.Profile.Roles = roles
+	return info
+}
+
+func syncersByType(syncers []connectorbuilder.ResourceSyncer) map[string]connectorbuilder.ResourceSyncer {
+	byType := make(map[string]connectorbuilder.ResourceSyncer)
+	for _, syncer := range syncers {
+		byType[syncer.ResourceType(context.Background()).Id] = syncer
+	}
+	return byType
+}
+
+func TestCapabilitiesOf(t *testing.T) {
+	require.Empty(t, capabilitiesOf([]string{"ADMIN"}).missing())
+	require.Len(t, capabilitiesOf(nil).missing(), len(keyCapabilitiesList))
+
+	usersAdmin := capabilitiesOf([]string{"USERS_ADMIN", "ANALYTICS_USER"})
+	require.True(t, usersAdmin.has(capabilitySyncUsers))
+	require.True(t, usersAdmin.has(capabilityReadAnalytics))
+	require.False(t, usersAdmin.has(capabilitySyncGroups))
+	require.False(t, usersAdmin.has(capabilityManageRoles))
+	require.False(t, usersAdmin.has(capabilityManageUsers))
+	require.False(t, usersAdmin.has(capabilityManageApiKeys))
+}
+
+func TestConnectorCapabilities(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ADMIN key syncs and provisions everything", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("GetAuthenticationInfo", mock.Anything).Return(authenticationInfo("ADMIN"), annotations.Annotations{}, nil).Once()
+		d := &Connector{client: mockClient}
+
+		_, err := d.Validate(ctx)
+		require.NoError(t, err)
+
+		syncers := syncersByType(d.ResourceSyncers(ctx))
+		require.Contains(t, syncers, realmResourceType.Id)
+		require.Contains(t, syncers, mappingRuleResourceType.Id)
+		require.Implements(t, (*connectorbuilder.ResourceProvisionerV2)(nil), syncers[roleResourceType.Id])
+		require.Implements(t, (*connectorbuilder.ResourceProvisionerV2)(nil), syncers[realmResourceType.Id])
+		require.Implements(t, (*connectorbuilder.AccountManager)(nil), syncers[userResourceType.Id])
+		require.NotImplements(t, (*connectorbuilder.ResourceDeleter)(nil), syncers[userResourceType.Id])
+		require.Implements(t, (*connectorbuilder.ResourceProvisioner)(nil), syncers[savedSearchResourceType.Id])
+
+		// The roles of the key are read once.
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("USERS_ADMIN key only syncs users", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("GetAuthenticationInfo", mock.Anything).Return(authenticationInfo("USERS_ADMIN"), annotations.Annotations{}, nil)
+		d := &Connector{client: mockClient, userActivityMetrics: true}
+
+		_, err := d.Validate(ctx)
+		require.NoError(t, err)
+
+		syncers := syncersByType(d.ResourceSyncers(ctx))
+		require.Contains(t, syncers, userResourceType.Id)
+		require.Contains(t, syncers, roleResourceType.Id)
+		require.NotContains(t, syncers, realmResourceType.Id)
+		require.NotContains(t, syncers, mappingRuleResourceType.Id)
+
+		for resourceType, syncer := range syncers {
+			require.NotImplements(t, (*connectorbuilder.ResourceProvisioner)(nil), syncer, resourceType)
+			require.NotImplements(t, (*connectorbuilder.ResourceProvisionerV2)(nil), syncer, resourceType)
+			require.NotImplements(t, (*connectorbuilder.AccountManager)(nil), syncer, resourceType)
+			require.NotImplements(t, (*connectorbuilder.ResourceDeleter)(nil), syncer, resourceType)
+		}
+
+		users, ok := syncers[userResourceType.Id].(syncOnly).ResourceSyncer.(*userBuilder)
+		require.True(t, ok)
+		require.False(t, users.activityMetrics)
+	})
+
+	t.Run("USERS_ADMIN key has no usage events nor admin actions", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("GetAuthenticationInfo", mock.Anything).Return(authenticationInfo("USERS_ADMIN"), annotations.Annotations{}, nil)
+		mockClient.On("ListUserChanges", mock.Anything, mock.Anything).Return(client.UserChangesResponse{}, annotations.Annotations{}, nil).Once()
+		d := &Connector{
+			client:  mockClient,
+			events:  newEventFeed(mockClient),
+			actions: newCustomActions(mockClient, newManualRolesUpdater(mockClient)),
+		}
+
+		server, err := connectorbuilder.NewConnector(ctx, d)
+		require.NoError(t, err)
+
+		schemas, err := server.ListActionSchemas(ctx, &v2.ListActionSchemasRequest{})
+		require.NoError(t, err)
+		require.Len(t, schemas.Schemas, 1)
+		require.Equal(t, findInactiveUsersSchema.Name, schemas.Schemas[0].Name)
+
+		// The analytics are not read, only the users history is.
+		events, _, _, err := d.ListEvents(ctx, nil, &pagination.StreamToken{Size: 10})
+		require.NoError(t, err)
+		require.Empty(t, events)
+		mockClient.AssertNotCalled(t, "ListAnalyticsEvents", mock.Anything, mock.Anything)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("key without users access fails validation", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("GetAuthenticationInfo", mock.Anything).Return(authenticationInfo("PRINT_USER"), annotations.Annotations{}, nil)
+		d := &Connector{client: mockClient}
+
+		_, err := d.Validate(ctx)
+		require.ErrorContains(t, err, "USERS_ADMIN")
+	})
+
+	t.Run("roles are read again after a failure", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("GetAuthenticationInfo", mock.Anything).Return(client.AuthenticationInfo{}, annotations.Annotations{}, errors.New("unavailable")).Once()
+		mockClient.On("GetAuthenticationInfo", mock.Anything).Return(authenticationInfo("USERS_ADMIN"), annotations.Annotations{}, nil).Once()
+		d := &Connector{client: mockClient}
+
+		// All the syncers are kept when the roles cannot be read.
+		syncers := syncersByType(d.ResourceSyncers(ctx))
+		require.Contains(t, syncers, realmResourceType.Id)
+
+		_, err := d.Validate(ctx)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,46 @@
+package connector
+
+import "sync"
+
+// keyedLocks hands out one mutex per key, for example a user or a realm ID.
+// Unused mutexes are dropped so the map does not grow with every key ever seen.
+type keyedLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock blocks until the caller holds the lock of the given key, the returned function releases it.
+func (k *keyedLocks) lock(key string) func() {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+
+	return func() {
+		l.Unlock()
+
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+func newKeyedLocks() *keyedLocks {
+	return &keyedLocks{
+		locks: make(map[string]*keyedLock),
+	}
+}
@@ -0,0 +1,127 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const maxManualRolesUpdateAttempts = 3
+
+// manualRolesRetryDelay is the base wait between two update attempts, it grows linearly with each attempt.
+var manualRolesRetryDelay = 250 * time.Millisecond
+
+var errManualRolesConflict = errors.New("manual roles were modified concurrently")
+
+// manualRolesUpdater applies changes to the manual roles of users.
+// Fluid Topics only allows to replace the whole manualRoles list, so every change is a read-modify-write cycle.
+// The cycles are serialized per user inside the connector, and every write is verified by reading the roles back.
+type manualRolesUpdater struct {
+	client client.FluidTopicsClientInterface
+	locks  *keyedLocks
+	// dryRun is set when the client does not send the updates, they cannot be verified.
+	dryRun bool
+}
+
+// update adds and removes the given roles from the manual roles of the user.
+// It returns false when the user was already in the requested state and nothing had to be written.
+func (m *manualRolesUpdater) update(ctx context.Context, userID string, add []string, remove []string) (bool, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	unlock := m.locks.lock(userID)
+	defer unlock()
+
+	userRoles, _, err := m.client.GetRolesByUserID(ctx, userID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var annotation annotations.Annotations
+	for attempt := 0; ; attempt++ {
+		updatedRoles, changed := applyManualRolesChange(userRoles.ManualRoles, add, remove)
+		if !changed {
+			return attempt > 0, annotation, nil
+		}
+
+		if attempt == maxManualRolesUpdateAttempts {
+			return false, nil, fmt.Errorf(
+				"%w: user %s still has manual roles %v after %d attempts (add %v, remove %v)",
+				errManualRolesConflict, userID, userRoles.ManualRoles, attempt, add, remove,
+			)
+		}
+
+		if attempt > 0 {
+			l.Warn("manual roles update was not applied, retrying",
+				zap.String("user_id", userID),
+				zap.Int("attempt", attempt),
+				zap.Strings("manual_roles", userRoles.ManualRoles),
+			)
+			if err := wait(ctx, time.Duration(attempt)*manualRolesRetryDelay); err != nil {
+				return false, nil, err
+			}
+		}
+
+		// The audit log records the roles replaced by the update, they were just read.
+		auditCtx := client.WithAuditManualRolesBefore(ctx, userRoles.ManualRoles)
+		annotation, err = m.client.UpdateUserManualRoles(auditCtx, userID, updatedRoles)
+		if err != nil {
+			return false, nil, err
+		}
+		if m.dryRun {
+			return true, annotation, nil
+		}
+
+		userRoles, _, err = m.client.GetRolesByUserID(ctx, userID)
+		if err != nil {
+			return false, nil, fmt.Errorf("error verifying manual roles of user %s: %w", userID, err)
+		}
+	}
+}
+
+// applyManualRolesChange returns the roles after adding and removing the given ones, and whether they differ from the current roles.
+func applyManualRolesChange(current []string, add []string, remove []string) ([]string, bool) {
+	updated := []string{}
+	changed := false
+	for _, role := range current {
+		if slices.Contains(remove, role) {
+			changed = true
+			continue
+		}
+		updated = append(updated, role)
+	}
+
+	for _, role := range add {
+		if !slices.Contains(updated, role) {
+			updated = append(updated, role)
+			changed = true
+		}
+	}
+
+	return updated, changed
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func newManualRolesUpdater(c client.FluidTopicsClientInterface) *manualRolesUpdater {
+	return &manualRolesUpdater{
+		client: c,
+		locks:  newKeyedLocks(),
+	}
+}
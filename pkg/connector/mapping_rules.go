@@ -0,0 +1,80 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+type mappingRuleBuilder struct {
+	resourceType *v2.ResourceType
+	client       client.FluidTopicsClientInterface
+}
+
+func (m *mappingRuleBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return mappingRuleResourceType
+}
+
+// List returns the mapping rules of a realm, mapping rules are only listed under their realm.
+func (m *mappingRuleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	var resources []*v2.Resource
+
+	rules, annotation, err := m.client.GetRealmMappingRules(ctx, parentResourceID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for _, rule := range rules {
+		ruleResource, err := parseIntoMappingRuleResource(parentResourceID, rule)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, ruleResource)
+	}
+
+	return resources, "", annotation, nil
+}
+
+// Entitlements always returns an empty slice for mapping rules.
+func (m *mappingRuleBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// The roles and groups conferred by the mapping rules are granted by the realm builder.
+func (m *mappingRuleBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func parseIntoMappingRuleResource(realmID *v2.ResourceId, rule client.RealmMappingRule) (*v2.Resource, error) {
+	displayName := fmt.Sprintf("%s = %s", rule.Attribute, rule.Value)
+
+	ret, err := rs.NewResource(
+		displayName,
+		mappingRuleResourceType,
+		mappingRuleId(realmID.Resource, rule.Attribute, rule.Value),
+		rs.WithParentResourceID(realmID),
+		rs.WithDescription(fmt.Sprintf("Users of realm %s whose attribute %s is %s", realmID.Resource, rule.Attribute, rule.Value)),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func newMappingRuleBuilder(c client.FluidTopicsClientInterface) *mappingRuleBuilder {
+	return &mappingRuleBuilder{
+		resourceType: mappingRuleResourceType,
+		client:       c,
+	}
+}
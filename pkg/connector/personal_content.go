@@ -0,0 +1,269 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const (
+	contentOwnerEntitlement      = "owner"
+	contentSharedWithEntitlement = "shared_with"
+)
+
+// personalContentBuilder syncs one kind of shareable personal content, personal books or collections.
+type personalContentBuilder struct {
+	resourceType *v2.ResourceType
+	client       client.FluidTopicsClientInterface
+	kind         string
+
+	mu sync.Mutex
+	// shares are the users and groups each item is shared with, by resource ID, from the last listing of its owner.
+	shares map[string][]client.ContentShare
+}
+
+func (p *personalContentBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return p.resourceType
+}
+
+// List returns the personal content of a user, personal content is only listed under its owner.
+func (p *personalContentBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	var resources []*v2.Resource
+
+	items, annotation, err := p.client.ListUserContent(ctx, parentResourceID.Resource, p.kind)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	p.storeShares(parentResourceID.Resource, items)
+	for _, item := range items {
+		itemResource, err := p.parseIntoPersonalContentResource(parentResourceID, item)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, itemResource)
+	}
+
+	return resources, "", annotation, nil
+}
+
+// Entitlements returns the owner entitlement, and the shared_with entitlement held by the users and the groups the
+// content is shared with.
+func (p *personalContentBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return []*v2.Entitlement{
+		entitlement.NewPermissionEntitlement(
+			resource,
+			contentOwnerEntitlement,
+			entitlement.WithGrantableTo(userResourceType),
+			entitlement.WithDisplayName(fmt.Sprintf("%s owner", resource.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Owner of the %s %s", strings.ToLower(p.resourceType.DisplayName), resource.DisplayName)),
+		),
+		entitlement.NewPermissionEntitlement(
+			resource,
+			contentSharedWithEntitlement,
+			entitlement.WithGrantableTo(userResourceType, groupResourceType),
+			entitlement.WithDisplayName(fmt.Sprintf("%s shared with", resource.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Shared access to the %s %s", strings.ToLower(p.resourceType.DisplayName), resource.DisplayName)),
+		),
+	}, "", nil, nil
+}
+
+// Grants returns the owner grant and a shared_with grant for every user and group the content is shared with, the
+// grants of the groups expand to their members. The shares come from the listing of the owner, it is only listed
+// again when the item was not.
+func (p *personalContentBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	ownerID, _, err := parsePersonalContentId(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	shares, ok := p.itemShares(resource.Id.Resource)
+	if !ok {
+		items, _, err := p.client.ListUserContent(ctx, ownerID, p.kind)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		p.storeShares(ownerID, items)
+
+		if shares, ok = p.itemShares(resource.Id.Resource); !ok {
+			return nil, "", nil, nil
+		}
+	}
+
+	grants := []*v2.Grant{
+		grant.NewGrant(resource, contentOwnerEntitlement, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: ownerID}),
+	}
+	for _, share := range shares {
+		switch share.Type {
+		case client.ContentShareUser:
+			grants = append(grants, grant.NewGrant(resource, contentSharedWithEntitlement, &v2.ResourceId{
+				ResourceType: userResourceType.Id,
+				Resource:     share.Id,
+			}))
+		case client.ContentShareGroup:
+			groupID := &v2.ResourceId{ResourceType: groupResourceType.Id, Resource: share.Id}
+			grants = append(grants, grant.NewGrant(resource, contentSharedWithEntitlement, groupID,
+				grant.WithAnnotation(&v2.GrantExpandable{
+					EntitlementIds: []string{entitlement.NewEntitlementID(&v2.Resource{Id: groupID}, groupMemberEntitlement)},
+				}),
+			))
+		}
+	}
+
+	return grants, "", nil, nil
+}
+
+// storeShares records the shares of the items of the owner, for their grants.
+func (p *personalContentBuilder) storeShares(ownerID string, items []client.UserContentItem) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.shares == nil {
+		p.shares = make(map[string][]client.ContentShare)
+	}
+	for _, item := range items {
+		p.shares[personalContentId(ownerID, item.Id)] = item.Shares
+	}
+}
+
+// itemShares returns the shares of the item recorded by the listing of its owner.
+func (p *personalContentBuilder) itemShares(resourceID string) ([]client.ContentShare, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	shares, ok := p.shares[resourceID]
+	return shares, ok
+}
+
+// Grant is not supported, personal content is shared by its owner in Fluid Topics.
+func (p *personalContentBuilder) Grant(_ context.Context, _ *v2.Resource, _ *v2.Entitlement) (annotations.Annotations, error) {
+	return nil, fmt.Errorf("%s can only be shared by their owner in Fluid Topics", strings.ToLower(p.resourceType.DisplayName))
+}
+
+// Revoke stops sharing the content with the principal of the grant, ownership cannot be revoked.
+func (p *personalContentBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	if !strings.HasSuffix(g.Entitlement.Id, ":"+contentSharedWithEntitlement) {
+		return nil, fmt.Errorf("only the shares of a %s can be revoked", strings.ToLower(p.resourceType.DisplayName))
+	}
+	ctx = client.WithAuditTask(ctx, auditTaskRevoke, g.Id)
+
+	ownerID, itemID, err := parsePersonalContentId(g.Entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	share := client.ContentShare{Type: client.ContentShareUser, Id: g.Principal.Id.Resource}
+	if g.Principal.Id.ResourceType == groupResourceType.Id {
+		share.Type = client.ContentShareGroup
+	}
+
+	item, _, err := p.getItem(ctx, ownerID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil || !slices.ContainsFunc(item.Shares, func(s client.ContentShare) bool {
+		return s.Type == share.Type && s.Id == share.Id
+	}) {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	annotation, err := p.client.UnshareUserContent(ctx, ownerID, p.kind, itemID, share)
+	if err != nil {
+		return nil, err
+	}
+
+	return annotation, nil
+}
+
+// getItem returns the item of personal content of the owner, or nil when it no longer exists.
+func (p *personalContentBuilder) getItem(ctx context.Context, ownerID string, itemID string) (*client.UserContentItem, annotations.Annotations, error) {
+	items, annotation, err := p.client.ListUserContent(ctx, ownerID, p.kind)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx := slices.IndexFunc(items, func(item client.UserContentItem) bool { return item.Id == itemID })
+	if idx == -1 {
+		return nil, annotation, nil
+	}
+
+	return &items[idx], annotation, nil
+}
+
+func (p *personalContentBuilder) parseIntoPersonalContentResource(ownerID *v2.ResourceId, item client.UserContentItem) (*v2.Resource, error) {
+	displayName := item.Title
+	if displayName == "" {
+		displayName = item.Id
+	}
+
+	ret, err := rs.NewResource(
+		displayName,
+		p.resourceType,
+		personalContentId(ownerID.Resource, item.Id),
+		rs.WithParentResourceID(ownerID),
+		rs.WithDescription(fmt.Sprintf("%s of user %s, shared with %d users and %d groups", p.resourceType.DisplayName, ownerID.Resource,
+			countShares(item.Shares, client.ContentShareUser), countShares(item.Shares, client.ContentShareGroup))),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// countShares returns the number of shares of the type, with users or with groups.
+func countShares(shares []client.ContentShare, shareType string) int {
+	count := 0
+	for _, share := range shares {
+		if share.Type == shareType {
+			count++
+		}
+	}
+	return count
+}
+
+// personalContentId builds the ID of an item of personal content, the owner is needed to reach the item.
+func personalContentId(ownerID string, itemID string) string {
+	return fmt.Sprintf("%s/%s", ownerID, itemID)
+}
+
+// parsePersonalContentId returns the owner and the item of a personal content resource ID.
+func parsePersonalContentId(id string) (string, string, error) {
+	ownerID, itemID, ok := strings.Cut(id, "/")
+	if !ok || ownerID == "" || itemID == "" {
+		return "", "", fmt.Errorf("unexpected personal content id format: %q", id)
+	}
+
+	return ownerID, itemID, nil
+}
+
+func newPersonalBookBuilder(c client.FluidTopicsClientInterface) *personalContentBuilder {
+	return &personalContentBuilder{
+		resourceType: personalBookResourceType,
+		client:       c,
+		kind:         client.UserContentPersonalBooks,
+	}
+}
+
+func newCollectionBuilder(c client.FluidTopicsClientInterface) *personalContentBuilder {
+	return &personalContentBuilder{
+		resourceType: collectionResourceType,
+		client:       c,
+		kind:         client.UserContentCollections,
+	}
+}
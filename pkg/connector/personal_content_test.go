@@ -0,0 +1,115 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalContentBuilder(t *testing.T) {
+	ctx := context.Background()
+	owner := &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "writer"}
+
+	book := client.UserContentItem{
+		Id:    "book-1",
+		Title: "Install guide",
+		Shares: []client.ContentShare{
+			{Type: client.ContentShareUser, Id: "reader"},
+			{Type: client.ContentShareGroup, Id: "partners"},
+		},
+	}
+	otherBook := client.UserContentItem{
+		Id:     "book-2",
+		Title:  "Release notes",
+		Shares: []client.ContentShare{{Type: client.ContentShareUser, Id: "editor"}},
+	}
+
+	newBuilder := func() (*personalContentBuilder, *client.MockFluidTopicsClient) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("ListUserContent", mock.Anything, "writer", client.UserContentPersonalBooks).
+			Return([]client.UserContentItem{book, otherBook}, annotations.Annotations{}, nil)
+		return newPersonalBookBuilder(mockClient), mockClient
+	}
+
+	t.Run("Lists the books of their owner with owner and shared_with grants", func(t *testing.T) {
+		pb, mockClient := newBuilder()
+
+		resources, _, _, err := pb.List(ctx, nil, nil)
+		require.NoError(t, err)
+		require.Empty(t, resources)
+
+		resources, _, _, err = pb.List(ctx, owner, nil)
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+		require.Equal(t, "writer/book-1", resources[0].Id.Resource)
+		require.Equal(t, owner, resources[0].ParentResourceId)
+
+		entitlements, _, _, err := pb.Entitlements(ctx, resources[0], nil)
+		require.NoError(t, err)
+		require.Len(t, entitlements, 2)
+
+		grants, _, _, err := pb.Grants(ctx, resources[0], nil)
+		require.NoError(t, err)
+		require.Len(t, grants, 3)
+		require.Equal(t, "personal_book:writer/book-1:owner", grants[0].Entitlement.Id)
+		require.Equal(t, "writer", grants[0].Principal.Id.Resource)
+		require.Equal(t, "personal_book:writer/book-1:shared_with", grants[1].Entitlement.Id)
+		require.Equal(t, userResourceType.Id, grants[1].Principal.Id.ResourceType)
+		require.Equal(t, "reader", grants[1].Principal.Id.Resource)
+
+		// The grant of a group expands to its members.
+		require.Equal(t, groupResourceType.Id, grants[2].Principal.Id.ResourceType)
+		require.Equal(t, "partners", grants[2].Principal.Id.Resource)
+		expandable := &v2.GrantExpandable{}
+		annos := annotations.Annotations(grants[2].Annotations)
+		ok, err := annos.Pick(expandable)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"group:partners:member"}, expandable.EntitlementIds)
+
+		grants, _, _, err = pb.Grants(ctx, resources[1], nil)
+		require.NoError(t, err)
+		require.Len(t, grants, 2)
+		require.Equal(t, "editor", grants[1].Principal.Id.Resource)
+
+		// The grants reuse the listing of the owner.
+		mockClient.AssertNumberOfCalls(t, "ListUserContent", 1)
+	})
+
+	t.Run("Revoke removes a share", func(t *testing.T) {
+		pb, mockClient := newBuilder()
+		mockClient.On("UnshareUserContent", mock.Anything, "writer", client.UserContentPersonalBooks, "book-1",
+			client.ContentShare{Type: client.ContentShareUser, Id: "reader"}).Return(annotations.Annotations{}, nil).Once()
+
+		resources, _, _, err := pb.List(ctx, owner, nil)
+		require.NoError(t, err)
+		grants, _, _, err := pb.Grants(ctx, resources[0], nil)
+		require.NoError(t, err)
+
+		_, err = pb.Revoke(ctx, grants[1])
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+
+		// A share with a group is revoked as a group share.
+		mockClient.On("UnshareUserContent", mock.Anything, "writer", client.UserContentPersonalBooks, "book-1",
+			client.ContentShare{Type: client.ContentShareGroup, Id: "partners"}).Return(annotations.Annotations{}, nil).Once()
+		_, err = pb.Revoke(ctx, grants[2])
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+
+		// Ownership cannot be revoked.
+		_, err = pb.Revoke(ctx, grants[0])
+		require.Error(t, err)
+
+		// A share that no longer exists is already revoked.
+		grants[1].Principal.Id.Resource = "former-reader"
+		annos, err := pb.Revoke(ctx, grants[1])
+		require.NoError(t, err)
+		require.True(t, annos.Contains(&v2.GrantAlreadyRevoked{}))
+	})
+}
@@ -0,0 +1,55 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyConnector(t *testing.T) {
+	ctx := context.Background()
+
+	mockClient := &client.MockFluidTopicsClient{}
+	mockClient.On("GetAuthenticationInfo", mock.Anything).Return(authenticationInfo("ADMIN"), annotations.Annotations{}, nil)
+
+	actions := newCustomActions(mockClient, newManualRolesUpdater(mockClient))
+	actions.readOnly = true
+	d := &Connector{
+		client:   mockClient,
+		events:   newEventFeed(mockClient),
+		actions:  actions,
+		readOnly: true,
+	}
+
+	server, err := connectorbuilder.NewConnector(ctx, d)
+	require.NoError(t, err)
+
+	metadata, err := server.GetMetadata(ctx, &v2.ConnectorServiceGetMetadataRequest{})
+	require.NoError(t, err)
+	require.Nil(t, metadata.Metadata.AccountCreationSchema)
+
+	capabilities := metadata.Metadata.Capabilities
+	for _, resourceTypeCapability := range capabilities.ResourceTypeCapabilities {
+		require.Equal(t, []v2.Capability{v2.Capability_CAPABILITY_SYNC}, resourceTypeCapability.Capabilities, resourceTypeCapability.ResourceType.Id)
+	}
+	require.Contains(t, capabilities.ConnectorCapabilities, v2.Capability_CAPABILITY_SYNC)
+	for _, capability := range []v2.Capability{
+		v2.Capability_CAPABILITY_PROVISION,
+		v2.Capability_CAPABILITY_ACCOUNT_PROVISIONING,
+		v2.Capability_CAPABILITY_RESOURCE_DELETE,
+		v2.Capability_CAPABILITY_RESOURCE_CREATE,
+	} {
+		require.NotContains(t, capabilities.ConnectorCapabilities, capability)
+	}
+
+	schemas, err := server.ListActionSchemas(ctx, &v2.ListActionSchemasRequest{})
+	require.NoError(t, err)
+	require.Len(t, schemas.Schemas, 1)
+	require.Equal(t, findInactiveUsersSchema.Name, schemas.Schemas[0].Name)
+}
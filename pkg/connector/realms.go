@@ -0,0 +1,386 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const (
+	realmRoleEntitlement  = "role"
+	realmGroupEntitlement = "group"
+
+	realmMappingWarning = "Changing a realm mapping rule changes the access of every user of the realm whose attribute matches the rule."
+)
+
+var errMappingRulesConflict = errors.New("mapping rules were modified concurrently")
+
+type realmBuilder struct {
+	resourceType *v2.ResourceType
+	client       client.FluidTopicsClientInterface
+	locks        *keyedLocks
+	// dryRun is set when the client does not send the updates, they cannot be verified.
+	dryRun bool
+}
+
+func (r *realmBuilder) ResourceType(ctx context.Context) *v2.ResourceType { return realmResourceType }
+
+// List returns the authentication realms of the tenant, each of them is the parent of its mapping rules.
+func (r *realmBuilder) List(ctx context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	var resources []*v2.Resource
+
+	realms, annotation, err := r.client.ListRealms(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for _, realm := range realms {
+		realmResource, err := parseIntoRealmResource(realm)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, realmResource)
+	}
+
+	return resources, "", annotation, nil
+}
+
+// Entitlements returns one entitlement for every role a mapping rule can confer, and one for every group the
+// mapping rules of the realm currently confer.
+func (r *realmBuilder) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	var entitlements []*v2.Entitlement
+
+	rules, annotation, err := r.client.GetRealmMappingRules(ctx, resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for _, roleName := range allRoleNames() {
+		entitlements = append(entitlements, newRealmEntitlement(resource, realmRoleEntitlement, roleName, getRoleDescription(roleName)))
+	}
+
+	var groups []string
+	for _, rule := range rules {
+		for _, group := range rule.Groups {
+			if !slices.Contains(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+	}
+	for _, group := range groups {
+		entitlements = append(entitlements, newRealmEntitlement(resource, realmGroupEntitlement, group, fmt.Sprintf("Member of the group %s", group)))
+	}
+
+	return entitlements, "", annotation, nil
+}
+
+// Grants returns a grant for every role and group conferred by a mapping rule of the realm.
+// The principal of those grants is the mapping rule, not the users it matches.
+func (r *realmBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	var grants []*v2.Grant
+	realmID := resource.Id.Resource
+
+	rules, annotation, err := r.client.GetRealmMappingRules(ctx, realmID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for _, rule := range rules {
+		ruleID := &v2.ResourceId{
+			ResourceType: mappingRuleResourceType.Id,
+			Resource:     mappingRuleId(realmID, rule.Attribute, rule.Value),
+		}
+		for _, roleName := range rule.Roles {
+			grants = append(grants, newRealmGrant(resource, realmRoleEntitlement, roleName, ruleID))
+		}
+		for _, group := range rule.Groups {
+			grants = append(grants, newRealmGrant(resource, realmGroupEntitlement, group, ruleID))
+		}
+	}
+
+	return grants, "", annotation, nil
+}
+
+// Grant adds the role or group of the entitlement to the mapping rule of the principal, creating the rule when
+// the realm has none for this attribute value yet.
+func (r *realmBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if principal.Id.ResourceType != mappingRuleResourceType.Id {
+		return nil, nil, fmt.Errorf("only realm mapping rules can be granted with realm entitlements")
+	}
+
+	realmID, kind, name, err := parseRealmEntitlementId(entitlement.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ruleRealmID, attribute, value, err := parseMappingRuleId(principal.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ruleRealmID != realmID {
+		return nil, nil, fmt.Errorf("mapping rule %q does not belong to realm %q", principal.Id.Resource, realmID)
+	}
+
+	l.Warn(realmMappingWarning,
+		zap.String("realm_id", realmID),
+		zap.String("attribute", attribute),
+		zap.String("value", value),
+		zap.String(kind, name),
+	)
+
+	changed, annotation, err := r.updateMappingRule(ctx, realmID, attribute, value, func(rule *client.RealmMappingRule) bool {
+		return addToMappingRule(rule, kind, name)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	realmResource := &v2.Resource{Id: &v2.ResourceId{ResourceType: realmResourceType.Id, Resource: realmID}}
+	grants := []*v2.Grant{newRealmGrant(realmResource, kind, name, principal.Id)}
+
+	if !changed {
+		return grants, annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
+
+	return grants, annotation, nil
+}
+
+// Revoke removes the role or group of the grant from its mapping rule, the rule is deleted once it confers nothing.
+func (r *realmBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	realmID, kind, name, err := parseRealmEntitlementId(grant.Entitlement.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleRealmID, attribute, value, err := parseMappingRuleId(grant.Principal.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if ruleRealmID != realmID {
+		return nil, fmt.Errorf("mapping rule %q does not belong to realm %q", grant.Principal.Id.Resource, realmID)
+	}
+
+	l.Warn(realmMappingWarning,
+		zap.String("realm_id", realmID),
+		zap.String("attribute", attribute),
+		zap.String("value", value),
+		zap.String(kind, name),
+	)
+
+	changed, annotation, err := r.updateMappingRule(ctx, realmID, attribute, value, func(rule *client.RealmMappingRule) bool {
+		return removeFromMappingRule(rule, kind, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !changed {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	return annotation, nil
+}
+
+// updateMappingRule applies change to the rule matching the attribute value and saves the mapping rules of the realm.
+// Mapping rules are replaced as a whole, so the updates of a realm are serialized inside the connector, and every
+// write is verified by reading the rules back.
+func (r *realmBuilder) updateMappingRule(
+	ctx context.Context,
+	realmID string,
+	attribute string,
+	value string,
+	change func(rule *client.RealmMappingRule) bool,
+) (bool, annotations.Annotations, error) {
+	unlock := r.locks.lock(realmID)
+	defer unlock()
+
+	rules, _, err := r.client.GetRealmMappingRules(ctx, realmID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	rules, changed := applyMappingRuleChange(rules, attribute, value, change)
+	if !changed {
+		return false, nil, nil
+	}
+
+	annotation, err := r.client.UpdateRealmMappingRules(ctx, realmID, rules)
+	if err != nil {
+		return false, nil, err
+	}
+	if r.dryRun {
+		return true, annotation, nil
+	}
+
+	rules, _, err = r.client.GetRealmMappingRules(ctx, realmID)
+	if err != nil {
+		return false, nil, fmt.Errorf("error verifying mapping rules of realm %s: %w", realmID, err)
+	}
+	if _, pending := applyMappingRuleChange(rules, attribute, value, change); pending {
+		return false, nil, fmt.Errorf("%w: the rule %s=%s of realm %s was not updated", errMappingRulesConflict, attribute, value, realmID)
+	}
+
+	return true, annotation, nil
+}
+
+// applyMappingRuleChange returns the rules after applying change to the rule matching the attribute value, and
+// whether they differ from the given rules. The rule is created when missing, and deleted once it confers nothing.
+func applyMappingRuleChange(
+	rules []client.RealmMappingRule,
+	attribute string,
+	value string,
+	change func(rule *client.RealmMappingRule) bool,
+) ([]client.RealmMappingRule, bool) {
+	idx := slices.IndexFunc(rules, func(rule client.RealmMappingRule) bool {
+		return rule.Attribute == attribute && rule.Value == value
+	})
+	if idx == -1 {
+		rules = append(rules, client.RealmMappingRule{Attribute: attribute, Value: value})
+		idx = len(rules) - 1
+	}
+
+	if !change(&rules[idx]) {
+		return rules, false
+	}
+
+	if len(rules[idx].Roles) == 0 && len(rules[idx].Groups) == 0 {
+		rules = slices.Delete(rules, idx, idx+1)
+	}
+
+	return rules, true
+}
+
+func addToMappingRule(rule *client.RealmMappingRule, kind string, name string) bool {
+	list := &rule.Roles
+	if kind == realmGroupEntitlement {
+		list = &rule.Groups
+	}
+
+	if slices.Contains(*list, name) {
+		return false
+	}
+	*list = append(*list, name)
+
+	return true
+}
+
+func removeFromMappingRule(rule *client.RealmMappingRule, kind string, name string) bool {
+	list := &rule.Roles
+	if kind == realmGroupEntitlement {
+		list = &rule.Groups
+	}
+
+	idx := slices.Index(*list, name)
+	if idx == -1 {
+		return false
+	}
+	*list = slices.Delete(*list, idx, idx+1)
+
+	return true
+}
+
+func newRealmEntitlement(resource *v2.Resource, kind string, name string, description string) *v2.Entitlement {
+	return entitlement.NewPermissionEntitlement(
+		resource,
+		fmt.Sprintf("%s:%s", kind, name),
+		entitlement.WithGrantableTo(mappingRuleResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("%s %s %s", resource.DisplayName, kind, name)),
+		entitlement.WithDescription(fmt.Sprintf("%s through the mapping rules of the realm. %s", description, realmMappingWarning)),
+	)
+}
+
+func newRealmGrant(resource *v2.Resource, kind string, name string, principal *v2.ResourceId) *v2.Grant {
+	return grant.NewGrant(
+		resource,
+		fmt.Sprintf("%s:%s", kind, name),
+		principal,
+		grant.WithGrantMetadata(map[string]interface{}{
+			"warning": realmMappingWarning,
+		}),
+	)
+}
+
+// parseRealmEntitlementId returns the realm, the kind (role or group) and the name of a realm entitlement.
+// The name is the rest of the ID, group names can hold colons.
+func parseRealmEntitlementId(entitlementId string) (string, string, string, error) {
+	parts := strings.SplitN(entitlementId, ":", 4)
+	if len(parts) != 4 || parts[0] != realmResourceType.Id || parts[1] == "" || parts[3] == "" {
+		return "", "", "", fmt.Errorf("unexpected realm entitlement id format: %q", entitlementId)
+	}
+
+	kind := parts[2]
+	if kind != realmRoleEntitlement && kind != realmGroupEntitlement {
+		return "", "", "", fmt.Errorf("unexpected realm entitlement id format: %q", entitlementId)
+	}
+
+	return parts[1], kind, parts[3], nil
+}
+
+// mappingRuleId builds the ID of the mapping rule resource matching the attribute value in the realm.
+func mappingRuleId(realmID string, attribute string, value string) string {
+	return fmt.Sprintf("%s/%s=%s", realmID, attribute, value)
+}
+
+// parseMappingRuleId returns the realm, the attribute and the value of a mapping rule resource ID.
+func parseMappingRuleId(ruleID string) (string, string, string, error) {
+	realmID, rest, ok := strings.Cut(ruleID, "/")
+	if !ok {
+		return "", "", "", fmt.Errorf("unexpected mapping rule id format: %q", ruleID)
+	}
+
+	attribute, value, ok := strings.Cut(rest, "=")
+	if !ok {
+		return "", "", "", fmt.Errorf("unexpected mapping rule id format: %q", ruleID)
+	}
+
+	return realmID, attribute, value, nil
+}
+
+func parseIntoRealmResource(realm client.Realm) (*v2.Resource, error) {
+	displayName := realm.Name
+	if displayName == "" {
+		displayName = realm.Id
+	}
+
+	ret, err := rs.NewResource(
+		displayName,
+		realmResourceType,
+		realm.Id,
+		rs.WithDescription(fmt.Sprintf("%s authentication realm", realm.Type)),
+		rs.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: mappingRuleResourceType.Id}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// newRealmBuilder returns the realm syncer, dryRun is set when the client does not send the updates.
+func newRealmBuilder(c client.FluidTopicsClientInterface, dryRun bool) *realmBuilder {
+	return &realmBuilder{
+		resourceType: realmResourceType,
+		client:       c,
+		locks:        newKeyedLocks(),
+		dryRun:       dryRun,
+	}
+}
@@ -0,0 +1,197 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealmBuilder_MappingRules(t *testing.T) {
+	ctx := context.Background()
+	realmID := "okta"
+	realmResource := &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: realmResourceType.Id, Resource: realmID},
+		DisplayName: "Okta",
+	}
+	principal := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: mappingRuleResourceType.Id,
+			Resource:     "okta/department=Documentation",
+		},
+	}
+	adminEntitlement := &v2.Entitlement{Id: "realm:okta:role:ADMIN"}
+
+	t.Run("Grants should return a grant per role and group of every rule", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRealmBuilder(mockClient, false)
+
+		mockClient.On("GetRealmMappingRules", ctx, realmID).Return([]client.RealmMappingRule{
+			{Attribute: "department", Value: "Documentation", Roles: []string{"KHUB_ADMIN"}, Groups: []string{"writers"}},
+			{Attribute: "groups", Value: "ft-admins", Roles: []string{"ADMIN"}},
+		}, annotations.Annotations{}, nil)
+
+		grants, _, _, err := rb.Grants(ctx, realmResource, nil)
+		require.NoError(t, err)
+
+		var actual []string
+		for _, g := range grants {
+			actual = append(actual, g.Entitlement.Id+"|"+g.Principal.Id.Resource)
+			require.NotEmpty(t, g.Annotations)
+		}
+		require.ElementsMatch(t, []string{
+			"realm:okta:role:KHUB_ADMIN|okta/department=Documentation",
+			"realm:okta:group:writers|okta/department=Documentation",
+			"realm:okta:role:ADMIN|okta/groups=ft-admins",
+		}, actual)
+	})
+
+	t.Run("Grant adds the role to the existing rule", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRealmBuilder(mockClient, false)
+
+		mockClient.On("GetRealmMappingRules", ctx, realmID).Return([]client.RealmMappingRule{
+			{Attribute: "department", Value: "Documentation", Roles: []string{"KHUB_ADMIN"}},
+		}, annotations.Annotations{}, nil).Once()
+		mockClient.On("UpdateRealmMappingRules", ctx, realmID, []client.RealmMappingRule{
+			{Attribute: "department", Value: "Documentation", Roles: []string{"KHUB_ADMIN", "ADMIN"}},
+		}).Return(annotations.Annotations{}, nil).Once()
+		mockClient.On("GetRealmMappingRules", ctx, realmID).Return([]client.RealmMappingRule{
+			{Attribute: "department", Value: "Documentation", Roles: []string{"KHUB_ADMIN", "ADMIN"}},
+		}, annotations.Annotations{}, nil).Once()
+
+		grants, _, err := rb.Grant(ctx, principal, adminEntitlement)
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		require.Equal(t, "realm:okta:role:ADMIN", grants[0].Entitlement.Id)
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Grant creates the rule when the attribute value has none", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRealmBuilder(mockClient, false)
+
+		mockClient.On("GetRealmMappingRules", ctx, realmID).
+			Return([]client.RealmMappingRule{}, annotations.Annotations{}, nil).Once()
+		mockClient.On("UpdateRealmMappingRules", ctx, realmID, []client.RealmMappingRule{
+			{Attribute: "department", Value: "Documentation", Roles: []string{"ADMIN"}},
+		}).Return(annotations.Annotations{}, nil).Once()
+		mockClient.On("GetRealmMappingRules", ctx, realmID).Return([]client.RealmMappingRule{
+			{Attribute: "department", Value: "Documentation", Roles: []string{"ADMIN"}},
+		}, annotations.Annotations{}, nil).Once()
+
+		_, _, err := rb.Grant(ctx, principal, adminEntitlement)
+		require.NoError(t, err)
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Grant of a role already conferred by the rule", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRealmBuilder(mockClient, false)
+
+		mockClient.On("GetRealmMappingRules", ctx, realmID).Return([]client.RealmMappingRule{
+			{Attribute: "department", Value: "Documentation", Roles: []string{"ADMIN"}},
+		}, annotations.Annotations{}, nil).Once()
+
+		_, annos, err := rb.Grant(ctx, principal, adminEntitlement)
+		require.NoError(t, err)
+		require.True(t, annos.Contains(&v2.GrantAlreadyExists{}))
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Revoke deletes the rule once it confers nothing", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRealmBuilder(mockClient, false)
+
+		mockClient.On("GetRealmMappingRules", ctx, realmID).Return([]client.RealmMappingRule{
+			{Attribute: "department", Value: "Documentation", Roles: []string{"ADMIN"}},
+			{Attribute: "groups", Value: "ft-admins", Roles: []string{"ADMIN"}},
+		}, annotations.Annotations{}, nil).Once()
+		mockClient.On("UpdateRealmMappingRules", ctx, realmID, []client.RealmMappingRule{
+			{Attribute: "groups", Value: "ft-admins", Roles: []string{"ADMIN"}},
+		}).Return(annotations.Annotations{}, nil).Once()
+		mockClient.On("GetRealmMappingRules", ctx, realmID).Return([]client.RealmMappingRule{
+			{Attribute: "groups", Value: "ft-admins", Roles: []string{"ADMIN"}},
+		}, annotations.Annotations{}, nil).Once()
+
+		_, err := rb.Revoke(ctx, &v2.Grant{Principal: principal, Entitlement: adminEntitlement})
+		require.NoError(t, err)
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Grant rejects a mapping rule of another realm", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRealmBuilder(mockClient, false)
+
+		_, _, err := rb.Grant(ctx, principal, &v2.Entitlement{Id: "realm:azure:role:ADMIN"})
+		require.Error(t, err)
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Revoke rejects a mapping rule of another realm", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRealmBuilder(mockClient, false)
+
+		_, err := rb.Revoke(ctx, &v2.Grant{Principal: principal, Entitlement: &v2.Entitlement{Id: "realm:azure:role:ADMIN"}})
+		require.ErrorContains(t, err, "does not belong to realm")
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Grant fails when the rules read back miss the change", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRealmBuilder(mockClient, false)
+
+		mockClient.On("GetRealmMappingRules", ctx, realmID).
+			Return([]client.RealmMappingRule{}, annotations.Annotations{}, nil).Twice()
+		mockClient.On("UpdateRealmMappingRules", ctx, realmID, []client.RealmMappingRule{
+			{Attribute: "department", Value: "Documentation", Roles: []string{"ADMIN"}},
+		}).Return(annotations.Annotations{}, nil).Once()
+
+		_, _, err := rb.Grant(ctx, principal, adminEntitlement)
+		require.ErrorIs(t, err, errMappingRulesConflict)
+
+		mockClient.AssertExpectations(t)
+	})
+}
+
+// TestRealmBuilder_GrantsThroughHTTP checks that a change starts from the rules Fluid Topics holds, so it does not
+// undo the previous change of the run.
+func TestRealmBuilder_GrantsThroughHTTP(t *testing.T) {
+	ctx := context.Background()
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: mappingRuleResourceType.Id, Resource: "okta/department=Documentation"}}
+
+	server := newFluidTopicsServer()
+	c := newFluidTopicsClient(t, server)
+	rb := newRealmBuilder(c, false)
+
+	_, _, err := rb.Grant(ctx, principal, &v2.Entitlement{Id: "realm:okta:role:ADMIN"})
+	require.NoError(t, err)
+	_, _, err = rb.Grant(ctx, principal, &v2.Entitlement{Id: "realm:okta:group:team:writers"})
+	require.NoError(t, err)
+
+	require.Equal(t, []client.RealmMappingRule{
+		{Attribute: "department", Value: "Documentation", Roles: []string{"ADMIN"}, Groups: []string{"team:writers"}},
+	}, server.rules["okta"])
+}
+
+func TestParseRealmEntitlementId(t *testing.T) {
+	realmID, kind, name, err := parseRealmEntitlementId("realm:okta:group:team:writers")
+	require.NoError(t, err)
+	require.Equal(t, "okta", realmID)
+	require.Equal(t, realmGroupEntitlement, kind)
+	require.Equal(t, "team:writers", name)
+
+	for _, id := range []string{"realm:okta:role", "realm:okta:member:ADMIN", "role:okta:role:ADMIN", "realm:okta:role:"} {
+		_, _, _, err := parseRealmEntitlementId(id)
+		require.Error(t, err, id)
+	}
+}
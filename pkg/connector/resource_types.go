@@ -16,4 +16,59 @@ var (
 		Id:          "Role",
 		DisplayName: "role",
 	}
+
+	// The group resource type is for the groups of the users, personal books and collections can be shared with them.
+	groupResourceType = &v2.ResourceType{
+		Id:          "group",
+		DisplayName: "Group",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_GROUP},
+	}
+
+	// The realm resource type is for the authentication realms, they hold the mapping rules conferring roles and groups.
+	realmResourceType = &v2.ResourceType{
+		Id:          "realm",
+		DisplayName: "Realm",
+	}
+
+	// The mapping rule resource type is for an identity provider attribute value matched by the mapping rules of a realm.
+	mappingRuleResourceType = &v2.ResourceType{
+		Id:          "mapping_rule",
+		DisplayName: "Mapping rule",
+	}
+
+	// The tenant resource type is for the Fluid Topics tenant itself, its profile holds the seat and license accounting.
+	tenantResourceType = &v2.ResourceType{
+		Id:          "tenant",
+		DisplayName: "Tenant",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
+	}
+
+	// The personal book and collection resource types are for the personal content of the users, listed under
+	// their owner since sharing them gives access to curated documentation.
+	personalBookResourceType = &v2.ResourceType{
+		Id:          "personal_book",
+		DisplayName: "Personal book",
+	}
+
+	collectionResourceType = &v2.ResourceType{
+		Id:          "collection",
+		DisplayName: "Collection",
+	}
+
+	// The saved search resource type is for the searches saved by the users, their alerts email new results.
+	savedSearchResourceType = &v2.ResourceType{
+		Id:          "saved_search",
+		DisplayName: "Saved search",
+	}
+
+	// The document and search resource types are only used as the targets of usage events, they are not synced.
+	documentResourceType = &v2.ResourceType{
+		Id:          "document",
+		DisplayName: "Document",
+	}
+
+	searchResourceType = &v2.ResourceType{
+		Id:          "search",
+		DisplayName: "Search",
+	}
 )
@@ -15,6 +15,7 @@ import (
 type roleBuilder struct {
 	resourceType *v2.ResourceType
 	client       client.FluidTopicsClientInterface
+	manualRoles  *manualRolesUpdater
 }
 
 var roles = map[string]string{
@@ -25,7 +26,7 @@ var roles = map[string]string{
 	"COLLECTION_USER":          "Can create collections",
 	"PRINT_USER":               "Can use the print feature in the Reader page",
 	"OFFLINE_USER":             "Can use offline features",
-	"SAVED_SEARCH_USER":        "Can save searches",
+	"SAVED_SEARCH_USER":        "Can save searches and subscribe to their alerts",
 	"BETA_USER":                "Can use beta features",
 	"DEBUG_USER":               "Can access debug tools",
 	"ANALYTICS_USER":           "Can see analytics",
@@ -100,44 +101,41 @@ func (r *roleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *
 	return entitlements, "", nil, nil
 }
 
-func (r *roleBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+// Grant adds the role to the manual roles of the user and returns the resulting grant,
+// built the same way as during the sync so ConductorOne can reconcile it right away.
+func (r *roleBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
 	if principal.Id.ResourceType != userResourceType.Id {
-		return nil, fmt.Errorf("only users can be granted with role membership")
+		return nil, nil, fmt.Errorf("only users can be granted with role membership")
 	}
+	ctx = client.WithAuditTask(ctx, auditTaskGrant, entitlement.Id)
 
 	userID := principal.Id.Resource
 
 	roleType, roleName, err := parseEntitlementId(entitlement.Id)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if roleType != manualRole {
-		return nil, fmt.Errorf("only manual roles can be granted")
+		return nil, nil, fmt.Errorf("only manual roles can be granted, authentication roles are granted through the realm mapping rules")
 	}
 
-	userRoles, _, err := r.client.GetRolesByUserID(ctx, userID)
+	changed, annotation, err := r.manualRoles.update(ctx, userID, []string{roleName}, nil)
 	if err != nil {
-		return nil, err
-	}
-
-	for _, existingRole := range userRoles.ManualRoles {
-		if existingRole == roleName {
-			return annotations.New(&v2.GrantAlreadyExists{}), nil
-		}
+		return nil, nil, err
 	}
 
-	userRoles.ManualRoles = append(userRoles.ManualRoles, roleName)
+	grants := []*v2.Grant{newRoleGrant(roleType, roleName, principal)}
 
-	annotation, err := r.client.UpdateUserManualRoles(ctx, userID, userRoles.ManualRoles)
-	if err != nil {
-		return nil, err
+	if !changed {
+		return grants, annotations.New(&v2.GrantAlreadyExists{}), nil
 	}
 
-	return annotation, nil
+	return grants, annotation, nil
 }
 
 func (r *roleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	ctx = client.WithAuditTask(ctx, auditTaskRevoke, grant.Id)
 	userID := grant.Principal.Id.Resource
 
 	roleType, roleName, err := parseEntitlementId(grant.Entitlement.Id)
@@ -146,35 +144,18 @@ func (r *roleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.
 	}
 
 	if roleType != manualRole {
-		return nil, fmt.Errorf("only manual roles can be revoked")
+		return nil, fmt.Errorf("only manual roles can be revoked, authentication roles are revoked through the realm mapping rules")
 	}
 
-	userRoles, _, err := r.client.GetRolesByUserID(ctx, userID)
+	changed, annotation, err := r.manualRoles.update(ctx, userID, nil, []string{roleName})
 	if err != nil {
 		return nil, err
 	}
 
-	updatedRoles := []string{}
-	found := false
-	for _, existingRole := range userRoles.ManualRoles {
-		if existingRole == roleName {
-			found = true
-			continue
-		}
-		updatedRoles = append(updatedRoles, existingRole)
-	}
-
-	if !found {
+	if !changed {
 		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
 	}
 
-	userRoles.ManualRoles = updatedRoles
-
-	annotation, err := r.client.UpdateUserManualRoles(ctx, userID, userRoles.ManualRoles)
-	if err != nil {
-		return nil, err
-	}
-
 	return annotation, nil
 }
 
@@ -202,9 +183,10 @@ func parseIntoRoleResource(_ context.Context, role client.Role) (*v2.Resource, e
 	return ret, nil
 }
 
-func newRoleBuilder(c client.FluidTopicsClientInterface) *roleBuilder {
+func newRoleBuilder(c client.FluidTopicsClientInterface, manualRoles *manualRolesUpdater) *roleBuilder {
 	return &roleBuilder{
 		resourceType: roleResourceType,
 		client:       c,
+		manualRoles:  manualRoles,
 	}
 }
@@ -2,12 +2,21 @@ package connector
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/conductorone/baton-fluid-topics/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -28,42 +37,74 @@ func TestRoleBuilder_GrantAndRevoke(t *testing.T) {
 
 	t.Run("Grant role to user", func(t *testing.T) {
 		mockClient := &client.MockFluidTopicsClient{}
-		rb := newRoleBuilder(mockClient)
+		rb := newRoleBuilder(mockClient, newManualRolesUpdater(mockClient))
 
-		mockClient.On("GetRolesByUserID", ctx, userID).
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
 			Return(client.UserRoles{ManualRoles: []string{}}, annotations.New(nil), nil).Once()
-		mockClient.On("UpdateUserManualRoles", ctx, userID, []string{roleName}).
+		mockClient.On("UpdateUserManualRoles", mock.Anything, userID, []string{roleName}).
 			Return(annotations.New(nil), nil).Once()
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
+			Return(client.UserRoles{ManualRoles: []string{roleName}}, annotations.New(nil), nil).Once()
 
-		annotationsTest, err := rb.Grant(ctx, principal, entitlement)
+		grantsTest, annotationsTest, err := rb.Grant(ctx, principal, entitlement)
 		require.NoError(t, err)
 		require.Empty(t, annotationsTest)
+		require.Len(t, grantsTest, 1)
+		require.Equal(t, "Role:manual:KHUB_ADMIN:assigned", grantsTest[0].Entitlement.Id)
+		require.Equal(t, userID, grantsTest[0].Principal.Id.Resource)
 
 		mockClient.AssertExpectations(t)
 	})
 
+	t.Run("Grant returns the same grant as the sync", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRoleBuilder(mockClient, newManualRolesUpdater(mockClient))
+		ub := newUserBuilder(mockClient, false, nil, nil)
+
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
+			Return(client.UserRoles{ManualRoles: []string{}}, annotations.New(nil), nil).Once()
+		mockClient.On("UpdateUserManualRoles", mock.Anything, userID, []string{roleName}).
+			Return(annotations.New(nil), nil).Once()
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
+			Return(client.UserRoles{ManualRoles: []string{roleName}}, annotations.New(nil), nil)
+
+		grantsTest, _, err := rb.Grant(ctx, principal, entitlement)
+		require.NoError(t, err)
+
+		syncedGrants, _, _, err := ub.Grants(ctx, principal, nil)
+		require.NoError(t, err)
+
+		require.Len(t, grantsTest, 1)
+		require.Len(t, syncedGrants, 1)
+		require.Equal(t, syncedGrants[0].Id, grantsTest[0].Id)
+		require.Equal(t, syncedGrants[0].Entitlement.Id, grantsTest[0].Entitlement.Id)
+	})
+
 	t.Run("Grant role that is already assigned", func(t *testing.T) {
 		mockClient := &client.MockFluidTopicsClient{}
-		rb := newRoleBuilder(mockClient)
+		rb := newRoleBuilder(mockClient, newManualRolesUpdater(mockClient))
 
-		mockClient.On("GetRolesByUserID", ctx, userID).
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
 			Return(client.UserRoles{ManualRoles: []string{roleName}}, annotations.New(nil), nil).Once()
 
-		annotationsTest, err := rb.Grant(ctx, principal, entitlement)
+		grantsTest, annotationsTest, err := rb.Grant(ctx, principal, entitlement)
 		require.NoError(t, err)
 		require.IsType(t, annotations.New(&v2.GrantAlreadyExists{}), annotationsTest)
+		require.Len(t, grantsTest, 1)
 
 		mockClient.AssertExpectations(t)
 	})
 
 	t.Run("Revoke existing role", func(t *testing.T) {
 		mockClient := &client.MockFluidTopicsClient{}
-		rb := newRoleBuilder(mockClient)
+		rb := newRoleBuilder(mockClient, newManualRolesUpdater(mockClient))
 
-		mockClient.On("GetRolesByUserID", ctx, userID).
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
 			Return(client.UserRoles{ManualRoles: []string{roleName}}, annotations.New(nil), nil).Once()
-		mockClient.On("UpdateUserManualRoles", ctx, userID, []string{}).
+		mockClient.On("UpdateUserManualRoles", mock.Anything, userID, []string{}).
 			Return(annotations.New(nil), nil).Once()
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
+			Return(client.UserRoles{ManualRoles: []string{}}, annotations.New(nil), nil).Once()
 
 		annotationsTest, err := rb.Revoke(ctx, grant)
 		require.NoError(t, err)
@@ -74,9 +115,9 @@ func TestRoleBuilder_GrantAndRevoke(t *testing.T) {
 
 	t.Run("Revoke non-assigned role", func(t *testing.T) {
 		mockClient := &client.MockFluidTopicsClient{}
-		rb := newRoleBuilder(mockClient)
+		rb := newRoleBuilder(mockClient, newManualRolesUpdater(mockClient))
 
-		mockClient.On("GetRolesByUserID", ctx, userID).
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
 			Return(client.UserRoles{ManualRoles: []string{}}, annotations.New(nil), nil).Once()
 
 		annotationsTest, err := rb.Revoke(ctx, grant)
@@ -88,31 +129,290 @@ func TestRoleBuilder_GrantAndRevoke(t *testing.T) {
 
 	t.Run("Grant fails if GetRolesByUserID returns error", func(t *testing.T) {
 		mockClient := &client.MockFluidTopicsClient{}
-		rb := newRoleBuilder(mockClient)
+		rb := newRoleBuilder(mockClient, newManualRolesUpdater(mockClient))
 
-		mockClient.On("GetRolesByUserID", ctx, userID).
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
 			Return(client.UserRoles{}, annotations.New(nil), errors.New("API failure")).Once()
 
-		annotationsTest, err := rb.Grant(ctx, principal, entitlement)
+		grantsTest, annotationsTest, err := rb.Grant(ctx, principal, entitlement)
 		require.Error(t, err)
 		require.Nil(t, annotationsTest)
+		require.Nil(t, grantsTest)
 
 		mockClient.AssertExpectations(t)
 	})
 
 	t.Run("Grant fails if UpdateUserManualRoles returns error", func(t *testing.T) {
 		mockClient := &client.MockFluidTopicsClient{}
-		rb := newRoleBuilder(mockClient)
+		rb := newRoleBuilder(mockClient, newManualRolesUpdater(mockClient))
 
-		mockClient.On("GetRolesByUserID", ctx, userID).
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
 			Return(client.UserRoles{ManualRoles: []string{}}, annotations.New(nil), nil).Once()
-		mockClient.On("UpdateUserManualRoles", ctx, userID, []string{roleName}).
+		mockClient.On("UpdateUserManualRoles", mock.Anything, userID, []string{roleName}).
 			Return(annotations.New(nil), errors.New("update failed")).Once()
 
-		annotationsTest, err := rb.Grant(ctx, principal, entitlement)
+		grantsTest, annotationsTest, err := rb.Grant(ctx, principal, entitlement)
 		require.Error(t, err)
 		require.Nil(t, annotationsTest)
+		require.Nil(t, grantsTest)
+
+		mockClient.AssertExpectations(t)
+	})
+}
+
+// lossyRolesClient simulates the Fluid Topics roles endpoints, every GET waits a little
+// so that unserialized read-modify-write cycles on the same user overwrite each other.
+type lossyRolesClient struct {
+	client.MockFluidTopicsClient
+
+	mu    sync.Mutex
+	roles map[string][]string
+}
+
+func (c *lossyRolesClient) GetRolesByUserID(_ context.Context, userID string) (client.UserRoles, annotations.Annotations, error) {
+	c.mu.Lock()
+	manualRoles := append([]string{}, c.roles[userID]...)
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	return client.UserRoles{Id: userID, ManualRoles: manualRoles}, nil, nil
+}
+
+func (c *lossyRolesClient) UpdateUserManualRoles(_ context.Context, userID string, manualRoles []string) (annotations.Annotations, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roles[userID] = manualRoles
+	return nil, nil
+}
+
+func TestRoleBuilder_ConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	retryDelay := manualRolesRetryDelay
+	manualRolesRetryDelay = time.Millisecond
+	t.Cleanup(func() {
+		manualRolesRetryDelay = retryDelay
+	})
+
+	userID := "user-123"
+	principal := &v2.Resource{
+		Id: &v2.ResourceId{
+			Resource:     userID,
+			ResourceType: userResourceType.Id,
+		},
+	}
+
+	t.Run("Concurrent grants for the same user are all kept", func(t *testing.T) {
+		fakeClient := &lossyRolesClient{roles: map[string][]string{}}
+		rb := newRoleBuilder(fakeClient, newManualRolesUpdater(fakeClient))
+
+		roleNames := []string{"PRINT_USER", "RATING_USER", "FEEDBACK_USER", "OFFLINE_USER"}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, len(roleNames))
+		for _, roleName := range roleNames {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, err := rb.Grant(ctx, principal, &v2.Entitlement{Id: "Role:manual:" + roleName + ":assigned"})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		require.ElementsMatch(t, roleNames, fakeClient.roles[userID])
+	})
+
+	t.Run("Grant retries when the update is not applied", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRoleBuilder(mockClient, newManualRolesUpdater(mockClient))
+
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
+			Return(client.UserRoles{ManualRoles: []string{"PRINT_USER"}}, annotations.New(nil), nil).Once()
+		mockClient.On("UpdateUserManualRoles", mock.Anything, userID, []string{"PRINT_USER", "KHUB_ADMIN"}).
+			Return(annotations.New(nil), nil).Once()
+		// Another writer replaced the roles between our write and the verification.
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
+			Return(client.UserRoles{ManualRoles: []string{"RATING_USER"}}, annotations.New(nil), nil).Once()
+		mockClient.On("UpdateUserManualRoles", mock.Anything, userID, []string{"RATING_USER", "KHUB_ADMIN"}).
+			Return(annotations.New(nil), nil).Once()
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
+			Return(client.UserRoles{ManualRoles: []string{"RATING_USER", "KHUB_ADMIN"}}, annotations.New(nil), nil).Once()
+
+		grantsTest, annotationsTest, err := rb.Grant(ctx, principal, &v2.Entitlement{Id: "Role:manual:KHUB_ADMIN:assigned"})
+		require.NoError(t, err)
+		require.Empty(t, annotationsTest)
+		require.Len(t, grantsTest, 1)
+		require.Equal(t, "Role:manual:KHUB_ADMIN:assigned", grantsTest[0].Entitlement.Id)
+		require.Equal(t, userID, grantsTest[0].Principal.Id.Resource)
 
 		mockClient.AssertExpectations(t)
 	})
+
+	t.Run("Grant fails when the update is never applied", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		rb := newRoleBuilder(mockClient, newManualRolesUpdater(mockClient))
+
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
+			Return(client.UserRoles{ManualRoles: []string{}}, annotations.New(nil), nil)
+		mockClient.On("UpdateUserManualRoles", mock.Anything, userID, []string{"KHUB_ADMIN"}).
+			Return(annotations.New(nil), nil).Times(maxManualRolesUpdateAttempts)
+
+		grantsTest, annotationsTest, err := rb.Grant(ctx, principal, &v2.Entitlement{Id: "Role:manual:KHUB_ADMIN:assigned"})
+		require.ErrorIs(t, err, errManualRolesConflict)
+		require.Nil(t, annotationsTest)
+		require.Nil(t, grantsTest)
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Dry run grant is not verified", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		manualRoles := newManualRolesUpdater(mockClient)
+		manualRoles.dryRun = true
+		rb := newRoleBuilder(mockClient, manualRoles)
+
+		mockClient.On("GetRolesByUserID", mock.Anything, userID).
+			Return(client.UserRoles{ManualRoles: []string{}}, annotations.New(nil), nil).Once()
+		mockClient.On("UpdateUserManualRoles", mock.Anything, userID, []string{"KHUB_ADMIN"}).
+			Return(annotations.New(nil), nil).Once()
+
+		grantsTest, annotationsTest, err := rb.Grant(ctx, principal, &v2.Entitlement{Id: "Role:manual:KHUB_ADMIN:assigned"})
+		require.NoError(t, err)
+		require.Empty(t, annotationsTest)
+		require.Len(t, grantsTest, 1)
+
+		mockClient.AssertExpectations(t)
+	})
+}
+
+// fluidTopicsServer serves the manual roles of the users and the mapping rules of the realms like Fluid Topics, and
+// counts the requests per method.
+type fluidTopicsServer struct {
+	mu       sync.Mutex
+	roles    map[string][]string
+	rules    map[string][]client.RealmMappingRule
+	requests map[string]int
+}
+
+func (s *fluidTopicsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.Method]++
+
+	w.Header().Set("Content-Type", "application/json")
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(segments) == 4 && segments[1] == "users" && segments[3] == "roles":
+		userID := segments[2]
+		var body client.UserRoles
+		if !serveResource(w, r, client.UserRoles{Id: userID, ManualRoles: s.roles[userID]}, &body) {
+			return
+		}
+		s.roles[userID] = body.ManualRoles
+	case len(segments) == 5 && segments[2] == "realms" && segments[4] == "mapping-rules":
+		realmID := segments[3]
+		var body client.RealmMapping
+		if !serveResource(w, r, client.RealmMapping{Rules: s.rules[realmID]}, &body) {
+			return
+		}
+		s.rules[realmID] = body.Rules
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// serveResource writes the resource on GET, and reports whether a PUT decoded a new one in body.
+func serveResource(w http.ResponseWriter, r *http.Request, resource interface{}, body interface{}) bool {
+	switch r.Method {
+	case http.MethodGet:
+		_ = json.NewEncoder(w).Encode(resource)
+	case http.MethodPut:
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return false
+		}
+		return true
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+	return false
+}
+
+func (s *fluidTopicsServer) setRoles(userID string, roles []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roles[userID] = roles
+}
+
+func newFluidTopicsServer() *fluidTopicsServer {
+	return &fluidTopicsServer{
+		roles:    map[string][]string{},
+		rules:    map[string][]client.RealmMappingRule{},
+		requests: map[string]int{},
+	}
+}
+
+// newFluidTopicsClient returns a client of the server, built like the connector builds it.
+func newFluidTopicsClient(t *testing.T, server *fluidTopicsServer, opts ...client.Option) *client.FluidTopicsClient {
+	httpServer := httptest.NewTLSServer(server)
+	t.Cleanup(httpServer.Close)
+
+	transport, ok := httpServer.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+
+	c, err := client.New(context.Background(), "token", httpServer.URL, append(opts, client.WithTLSClientConfig(transport.TLSClientConfig))...)
+	require.NoError(t, err)
+	return c
+}
+
+// TestRoleBuilder_GrantAndRevokeThroughHTTP checks that the updates, and the roles before them in the audit log, read
+// the roles Fluid Topics holds, not the GET responses cached by the HTTP client.
+func TestRoleBuilder_GrantAndRevokeThroughHTTP(t *testing.T) {
+	ctx := context.Background()
+	userID := "user-1"
+	principal := &v2.Resource{Id: &v2.ResourceId{Resource: userID, ResourceType: userResourceType.Id}}
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := client.NewAuditLog(auditPath)
+	require.NoError(t, err)
+
+	server := newFluidTopicsServer()
+	server.roles[userID] = []string{}
+	c := newFluidTopicsClient(t, server, client.WithAuditLog(auditLog))
+	rb := newRoleBuilder(c, newManualRolesUpdater(c))
+
+	// The sync reads the roles, then another administrator changes them.
+	_, _, err = c.GetRolesByUserID(ctx, userID)
+	require.NoError(t, err)
+	server.setRoles(userID, []string{"PRINT_USER"})
+
+	grants, _, err := rb.Grant(ctx, principal, &v2.Entitlement{Id: "Role:manual:ADMIN:assigned"})
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	require.Equal(t, []string{"PRINT_USER", "ADMIN"}, server.roles[userID])
+
+	_, err = rb.Revoke(ctx, grants[0])
+	require.NoError(t, err)
+	require.Equal(t, []string{"PRINT_USER"}, server.roles[userID])
+
+	// Each update is read, written and verified once.
+	require.Equal(t, 5, server.requests[http.MethodGet])
+	require.Equal(t, 2, server.requests[http.MethodPut])
+
+	data, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var entries [2]client.AuditEntry
+	for i, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &entries[i]))
+	}
+	require.Equal(t, &client.AuditManualRoles{Before: []string{"PRINT_USER"}, After: []string{"PRINT_USER", "ADMIN"}}, entries[0].ManualRoles)
+	require.Equal(t, &client.AuditManualRoles{Before: []string{"PRINT_USER", "ADMIN"}, After: []string{"PRINT_USER"}}, entries[1].ManualRoles)
 }
@@ -0,0 +1,221 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const savedSearchAlertEntitlement = "alert_subscription"
+
+type savedSearchBuilder struct {
+	resourceType *v2.ResourceType
+	client       client.FluidTopicsClientInterface
+
+	mu sync.Mutex
+	// searches are the saved searches by resource ID, from the last listing of their owner.
+	searches map[string]client.SavedSearch
+}
+
+func (s *savedSearchBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return savedSearchResourceType
+}
+
+// List returns the saved searches of a user, saved searches are only listed under their owner.
+func (s *savedSearchBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	var resources []*v2.Resource
+
+	searches, annotation, err := s.client.ListSavedSearches(ctx, parentResourceID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	s.storeSearches(parentResourceID.Resource, searches)
+	for _, search := range searches {
+		searchResource, err := parseIntoSavedSearchResource(parentResourceID, search)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, searchResource)
+	}
+
+	return resources, "", annotation, nil
+}
+
+// Entitlements returns the owner entitlement, and the alert subscription entitlement held by the owner while the
+// saved search sends email alerts.
+func (s *savedSearchBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return []*v2.Entitlement{
+		entitlement.NewPermissionEntitlement(
+			resource,
+			contentOwnerEntitlement,
+			entitlement.WithGrantableTo(userResourceType),
+			entitlement.WithDisplayName(fmt.Sprintf("%s owner", resource.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Owner of the saved search %s", resource.DisplayName)),
+		),
+		entitlement.NewPermissionEntitlement(
+			resource,
+			savedSearchAlertEntitlement,
+			entitlement.WithGrantableTo(userResourceType),
+			entitlement.WithDisplayName(fmt.Sprintf("%s alert subscription", resource.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Receives email alerts about the new results of the saved search %s", resource.DisplayName)),
+		),
+	}, "", nil, nil
+}
+
+// Grants returns the owner grant, and the alert subscription grant when the alerts of the saved search are on. The
+// saved search comes from the listing of its owner, it is only listed again when the search was not.
+func (s *savedSearchBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	ownerID, _, err := parsePersonalContentId(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	search, ok := s.listedSearch(resource.Id.Resource)
+	if !ok {
+		searches, _, err := s.client.ListSavedSearches(ctx, ownerID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		s.storeSearches(ownerID, searches)
+
+		if search, ok = s.listedSearch(resource.Id.Resource); !ok {
+			return nil, "", nil, nil
+		}
+	}
+
+	owner := &v2.ResourceId{ResourceType: userResourceType.Id, Resource: ownerID}
+	grants := []*v2.Grant{
+		grant.NewGrant(resource, contentOwnerEntitlement, owner),
+	}
+	if hasSavedSearchAlert(search) {
+		grants = append(grants, grant.NewGrant(resource, savedSearchAlertEntitlement, owner,
+			grant.WithGrantMetadata(map[string]interface{}{
+				"alert_frequency": search.AlertFrequency,
+			}),
+		))
+	}
+
+	return grants, "", nil, nil
+}
+
+// storeSearches records the saved searches of the owner, for their grants.
+func (s *savedSearchBuilder) storeSearches(ownerID string, searches []client.SavedSearch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.searches == nil {
+		s.searches = make(map[string]client.SavedSearch)
+	}
+	for _, search := range searches {
+		s.searches[personalContentId(ownerID, search.Id)] = search
+	}
+}
+
+// listedSearch returns the saved search recorded by the listing of its owner.
+func (s *savedSearchBuilder) listedSearch(resourceID string) (client.SavedSearch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search, ok := s.searches[resourceID]
+	return search, ok
+}
+
+// Grant is not supported, alerts are subscribed to by the owner of the saved search in Fluid Topics.
+func (s *savedSearchBuilder) Grant(_ context.Context, _ *v2.Resource, _ *v2.Entitlement) (annotations.Annotations, error) {
+	return nil, fmt.Errorf("saved search alerts can only be subscribed to by their owner in Fluid Topics")
+}
+
+// Revoke turns off the alerts of the saved search, ownership cannot be revoked.
+func (s *savedSearchBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	if !strings.HasSuffix(g.Entitlement.Id, ":"+savedSearchAlertEntitlement) {
+		return nil, fmt.Errorf("only the alert subscription of a saved search can be revoked")
+	}
+	ctx = client.WithAuditTask(ctx, auditTaskRevoke, g.Id)
+
+	ownerID, searchID, err := parsePersonalContentId(g.Entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	search, _, err := s.getSavedSearch(ctx, ownerID, searchID)
+	if err != nil {
+		return nil, err
+	}
+	if search == nil || !hasSavedSearchAlert(*search) {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	annotation, err := s.client.UpdateSavedSearchAlert(ctx, ownerID, searchID, client.SavedSearchAlertNone)
+	if err != nil {
+		return nil, err
+	}
+
+	return annotation, nil
+}
+
+// getSavedSearch returns the saved search of the owner, or nil when it no longer exists.
+func (s *savedSearchBuilder) getSavedSearch(ctx context.Context, ownerID string, searchID string) (*client.SavedSearch, annotations.Annotations, error) {
+	searches, annotation, err := s.client.ListSavedSearches(ctx, ownerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx := slices.IndexFunc(searches, func(search client.SavedSearch) bool { return search.Id == searchID })
+	if idx == -1 {
+		return nil, annotation, nil
+	}
+
+	return &searches[idx], annotation, nil
+}
+
+func hasSavedSearchAlert(search client.SavedSearch) bool {
+	return search.AlertFrequency != "" && !strings.EqualFold(search.AlertFrequency, client.SavedSearchAlertNone)
+}
+
+func parseIntoSavedSearchResource(ownerID *v2.ResourceId, search client.SavedSearch) (*v2.Resource, error) {
+	displayName := search.Title
+	if displayName == "" {
+		displayName = search.Query
+	}
+
+	alert := client.SavedSearchAlertNone
+	if hasSavedSearchAlert(search) {
+		alert = search.AlertFrequency
+	}
+
+	ret, err := rs.NewResource(
+		displayName,
+		savedSearchResourceType,
+		personalContentId(ownerID.Resource, search.Id),
+		rs.WithParentResourceID(ownerID),
+		rs.WithDescription(fmt.Sprintf("Search for %q saved by user %s, alert frequency: %s", search.Query, ownerID.Resource, alert)),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func newSavedSearchBuilder(c client.FluidTopicsClientInterface) *savedSearchBuilder {
+	return &savedSearchBuilder{
+		resourceType: savedSearchResourceType,
+		client:       c,
+	}
+}
@@ -0,0 +1,61 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedSearchBuilder(t *testing.T) {
+	ctx := context.Background()
+	owner := &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "writer"}
+
+	searches := []client.SavedSearch{
+		{Id: "search-1", Title: "Roadmap", Query: "roadmap 2027", AlertFrequency: "daily"},
+		{Id: "search-2", Query: "install", AlertFrequency: client.SavedSearchAlertNone},
+	}
+
+	mockClient := &client.MockFluidTopicsClient{}
+	sb := newSavedSearchBuilder(mockClient)
+	mockClient.On("ListSavedSearches", mock.Anything, "writer").Return(searches, annotations.Annotations{}, nil)
+
+	resources, _, _, err := sb.List(ctx, owner, nil)
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	require.Equal(t, "writer/search-1", resources[0].Id.Resource)
+	require.Equal(t, "install", resources[1].DisplayName)
+
+	t.Run("Only saved searches with alerts have an alert subscription grant", func(t *testing.T) {
+		grants, _, _, err := sb.Grants(ctx, resources[0], nil)
+		require.NoError(t, err)
+		require.Len(t, grants, 2)
+		require.Equal(t, "saved_search:writer/search-1:alert_subscription", grants[1].Entitlement.Id)
+
+		grants, _, _, err = sb.Grants(ctx, resources[1], nil)
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+
+		// The grants reuse the listing of the owner.
+		mockClient.AssertNumberOfCalls(t, "ListSavedSearches", 1)
+	})
+
+	t.Run("Revoke turns off the alerts", func(t *testing.T) {
+		mockClient.On("UpdateSavedSearchAlert", mock.Anything, "writer", "search-1", client.SavedSearchAlertNone).
+			Return(annotations.Annotations{}, nil).Once()
+
+		grants, _, _, err := sb.Grants(ctx, resources[0], nil)
+		require.NoError(t, err)
+
+		_, err = sb.Revoke(ctx, grants[1])
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+
+		_, err = sb.Revoke(ctx, grants[0])
+		require.Error(t, err)
+	})
+}
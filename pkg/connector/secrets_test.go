@@ -0,0 +1,110 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func newBufferLogger(w *bytes.Buffer) *zap.Logger {
+	return zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(w), zap.DebugLevel))
+}
+
+// requireNoSecret fails when the text holds the secret, as is or escaped in JSON.
+func requireNoSecret(t *testing.T, text string, secret string) {
+	escaped, err := json.Marshal(secret)
+	require.NoError(t, err)
+	require.NotContains(t, text, secret)
+	require.NotContains(t, text, string(escaped[1:len(escaped)-1]))
+}
+
+// TestNoSecretsInSyncState checks that the incremental sync state written to disk and the synced user resources do
+// not hold a password, even when the client returns one.
+func TestNoSecretsInSyncState(t *testing.T) {
+	const password = "s3cret-password"
+
+	var logs bytes.Buffer
+	ctx := ctxzap.ToContext(context.Background(), newBufferLogger(&logs))
+
+	user := client.User{
+		Id:          "user-1",
+		DisplayName: "Jane",
+		Email:       "jane@x.com",
+		Credentials: client.Credentials{Login: "jane@x.com", Password: password},
+	}
+
+	mockClient := &client.MockFluidTopicsClient{}
+	mockClient.On("ListUsers", mock.Anything).Return([]client.User{{Id: user.Id}}, "", annotations.Annotations{}, nil)
+	mockClient.On("GetUserDetails", mock.Anything, user.Id).Return(user, annotations.Annotations{}, nil)
+	mockClient.On("GetRolesByUserID", mock.Anything, user.Id).Return(client.UserRoles{}, annotations.Annotations{}, nil)
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	directory := newUserDirectory(mockClient, newUserSyncState(statePath, 24*time.Hour))
+	resources, _, _, err := newUserBuilder(mockClient, false, directory, nil).List(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+
+	state, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	require.Contains(t, string(state), user.Email)
+	require.NotContains(t, string(state), password)
+
+	data, err := protojson.Marshal(resources[0])
+	require.NoError(t, err)
+	require.NotContains(t, string(data), password)
+	require.NotContains(t, logs.String(), password)
+}
+
+// TestNoSecretsInAuditLogOrDryRunLogs checks that creating an account records its registration in the audit log and
+// the dry run logs without the generated password, which is only returned as plaintext data.
+func TestNoSecretsInAuditLogOrDryRunLogs(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := ctxzap.ToContext(context.Background(), newBufferLogger(&logs))
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := client.NewAuditLog(auditPath)
+	require.NoError(t, err)
+	c, err := client.New(ctx, "bearer-s3cret-token", "https://example.fluidtopics.net", client.WithDryRun(true), client.WithAuditLog(auditLog))
+	require.NoError(t, err)
+
+	profile, err := structpb.NewStruct(map[string]interface{}{"name": "John", "emailAddress": "john@x.com"})
+	require.NoError(t, err)
+	response, plaintexts, _, err := newUserBuilder(c, false, nil, nil).CreateAccount(ctx, &v2.AccountInfo{Profile: profile}, &v2.CredentialOptions{
+		Options: &v2.CredentialOptions_RandomPassword_{RandomPassword: &v2.CredentialOptions_RandomPassword{Length: 12}},
+	})
+	require.NoError(t, err)
+	require.Len(t, plaintexts, 1)
+	generatedPassword := string(plaintexts[0].Bytes)
+	require.NotEmpty(t, generatedPassword)
+
+	created, ok := response.(*v2.CreateAccountResponse_SuccessResult)
+	require.True(t, ok)
+	data, err := protojson.Marshal(created.Resource)
+	require.NoError(t, err)
+	requireNoSecret(t, string(data), generatedPassword)
+
+	audit, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+	require.Contains(t, string(audit), `"outcome":"dry_run"`)
+	require.Contains(t, string(audit), "john@x.com")
+	requireNoSecret(t, string(audit), generatedPassword)
+
+	require.Contains(t, logs.String(), "dry run, request not sent")
+	requireNoSecret(t, logs.String(), generatedPassword)
+	require.NotContains(t, logs.String(), "bearer-s3cret-token")
+}
@@ -0,0 +1,66 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var revokeSessionsSchema = &v2.BatonActionSchema{
+	Name:        "revoke_sessions",
+	DisplayName: "Revoke sessions",
+	Description: "Ends all the active sessions of the user and revokes its tokens, signing it out of Fluid Topics.",
+	Arguments: []*configv1.Field{
+		stringArgument("user_id", "User ID", "ID of the user to sign out.", true),
+	},
+	ReturnTypes: []*configv1.Field{
+		intArgument("sessions_terminated", "Sessions terminated", "Number of sessions ended.", true),
+		intArgument("tokens_revoked", "Tokens revoked", "Number of tokens revoked.", true),
+	},
+}
+
+func (a *customActions) revokeSessions(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	userID := stringArg(args, "user_id")
+	if userID == "" {
+		return nil, nil, fmt.Errorf("user_id is required")
+	}
+
+	revocation, annotation, err := revokeUserSessions(ctx, a.client, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ret, err := structpb.NewStruct(map[string]interface{}{
+		"user_id":             userID,
+		"sessions_terminated": revocation.Sessions,
+		"tokens_revoked":      revocation.Tokens,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ret, annotation, nil
+}
+
+// revokeUserSessions signs the user out, it is shared by the revoke_sessions action and the actions deleting users.
+func revokeUserSessions(ctx context.Context, c client.FluidTopicsClientInterface, userID string) (client.SessionRevocation, annotations.Annotations, error) {
+	revocation, annotation, err := c.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		return client.SessionRevocation{}, nil, fmt.Errorf("error revoking sessions of user %s: %w", userID, err)
+	}
+
+	ctxzap.Extract(ctx).Info("revoked user sessions",
+		zap.String("user_id", userID),
+		zap.Int("sessions_terminated", revocation.Sessions),
+		zap.Int("tokens_revoked", revocation.Tokens),
+	)
+
+	return revocation, annotation, nil
+}
@@ -0,0 +1,73 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestRevokeSessions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("revoke_sessions reports the sessions terminated", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		a := newCustomActions(mockClient, newManualRolesUpdater(mockClient))
+		mockClient.On("RevokeUserSessions", mock.Anything, "leaver").
+			Return(client.SessionRevocation{Sessions: 2, Tokens: 1}, annotations.Annotations{}, nil).Once()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"user_id": "leaver"})
+		require.NoError(t, err)
+		res, _, err := a.revokeSessions(ctx, args)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, res.Fields["sessions_terminated"].GetNumberValue())
+		require.EqualValues(t, 1, res.Fields["tokens_revoked"].GetNumberValue())
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("erase_user only revokes the sessions when enabled", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("GetUserDump", mock.Anything, "leaver").Return(map[string]json.RawMessage{}, annotations.Annotations{}, nil)
+		mockClient.On("DeleteUserContent", mock.Anything, "leaver", mock.Anything).Return(annotations.Annotations{}, nil)
+		mockClient.On("AnonymizeUser", mock.Anything, "leaver").Return(client.UserAnonymization{}, annotations.Annotations{}, nil)
+		mockClient.On("DeleteUser", mock.Anything, "leaver").Return(annotations.Annotations{}, nil)
+		a := newCustomActions(mockClient, newManualRolesUpdater(mockClient))
+
+		args, err := structpb.NewStruct(map[string]interface{}{"user_id": "leaver", "dry_run": false, "confirm": "leaver"})
+		require.NoError(t, err)
+		_, _, err = a.eraseUser(ctx, args)
+		require.NoError(t, err)
+		mockClient.AssertNotCalled(t, "RevokeUserSessions", mock.Anything, mock.Anything)
+
+		a.revokeSessionsOnDelete = true
+		mockClient.On("RevokeUserSessions", mock.Anything, "leaver").
+			Return(client.SessionRevocation{Sessions: 1}, annotations.Annotations{}, nil).Once()
+		_, _, err = a.eraseUser(ctx, args)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("erase_user keeps the account when its sessions cannot be revoked", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("GetUserDump", mock.Anything, "leaver").Return(map[string]json.RawMessage{}, annotations.Annotations{}, nil)
+		mockClient.On("DeleteUserContent", mock.Anything, "leaver", mock.Anything).Return(annotations.Annotations{}, nil)
+		mockClient.On("AnonymizeUser", mock.Anything, "leaver").Return(client.UserAnonymization{}, annotations.Annotations{}, nil)
+		mockClient.On("RevokeUserSessions", mock.Anything, "leaver").
+			Return(client.SessionRevocation{}, annotations.Annotations{}, errors.New("forbidden")).Once()
+		a := newCustomActions(mockClient, newManualRolesUpdater(mockClient))
+		a.revokeSessionsOnDelete = true
+
+		args, err := structpb.NewStruct(map[string]interface{}{"user_id": "leaver", "dry_run": false, "confirm": "leaver"})
+		require.NoError(t, err)
+		res, _, err := a.eraseUser(ctx, args)
+		require.NoError(t, err)
+		require.False(t, res.Fields["completed"].GetBoolValue())
+		mockClient.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+	})
+}
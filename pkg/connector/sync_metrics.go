@@ -0,0 +1,33 @@
+package connector
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-sdk/pkg/metrics"
+)
+
+// syncMetrics counts what the sync of the users produces.
+type syncMetrics struct {
+	usersProcessed metrics.Int64Counter
+	grantsEmitted  metrics.Int64Counter
+}
+
+// newSyncMetrics returns the sync metrics recorded through h, they are dropped when h is nil.
+func newSyncMetrics(ctx context.Context, h metrics.Handler) *syncMetrics {
+	if h == nil {
+		h = metrics.NewNoOpHandler(ctx)
+	}
+
+	return &syncMetrics{
+		usersProcessed: h.Int64Counter(
+			"fluid_topics_sync_users_processed",
+			"Number of users synced from Fluid Topics",
+			metrics.Dimensionless,
+		),
+		grantsEmitted: h.Int64Counter(
+			"fluid_topics_sync_grants_emitted",
+			"Number of role grants synced from Fluid Topics, by role type",
+			metrics.Dimensionless,
+		),
+	}
+}
@@ -0,0 +1,59 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// countingHandler sums the values added to its counters, per metric and role type.
+type countingHandler struct {
+	metrics.Handler
+	counts map[string]int64
+}
+
+type counter struct {
+	h    *countingHandler
+	name string
+}
+
+func (c *counter) Add(_ context.Context, value int64, tags map[string]string) {
+	c.h.counts[c.name+tags["role_type"]] += value
+}
+
+func (h *countingHandler) Int64Counter(name string, _ string, _ metrics.Unit) metrics.Int64Counter {
+	return &counter{h: h, name: name}
+}
+
+func TestUserBuilderSyncMetrics(t *testing.T) {
+	ctx := context.Background()
+	h := &countingHandler{counts: make(map[string]int64)}
+
+	mockClient := &client.MockFluidTopicsClient{}
+	ub := newUserBuilder(mockClient, false, nil, newSyncMetrics(ctx, h))
+
+	users := []client.User{{Id: "user-1"}, {Id: "user-2"}}
+	mockClient.On("ListUsers", mock.Anything).Return(users, "", annotations.Annotations{}, nil)
+	for _, user := range users {
+		mockClient.On("GetUserDetails", mock.Anything, user.Id).Return(user, annotations.Annotations{}, nil)
+	}
+	mockClient.On("GetRolesByUserID", mock.Anything, "user-1").Return(client.UserRoles{
+		ManualRoles:  []string{"ADMIN", "PRINT_USER"},
+		DefaultRoles: []string{"PRINT_USER"},
+	}, annotations.Annotations{}, nil)
+
+	resources, _, _, err := ub.List(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), h.counts["fluid_topics_sync_users_processed"])
+
+	_, _, _, err = ub.Grants(ctx, resources[0], nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), h.counts["fluid_topics_sync_grants_emittedmanual"])
+	require.Equal(t, int64(1), h.counts["fluid_topics_sync_grants_emitteddefault"])
+	require.Zero(t, h.counts["fluid_topics_sync_grants_emittedauthentication"])
+}
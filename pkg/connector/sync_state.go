@@ -0,0 +1,195 @@
+package connector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const defaultFullResyncInterval = 7 * 24 * time.Hour
+
+// userSyncState keeps the details and roles of the users from the previous sync in a local file, so a sync only
+// fetches the details of the users whose listing changed. The roles are fetched on every sync, a role change does not
+// show in the listing. Every full resync interval all the details are fetched again to catch the other changes the
+// listing does not show.
+type userSyncState struct {
+	path               string
+	fullResyncInterval time.Duration
+	now                func() time.Time
+
+	mu       sync.Mutex
+	previous syncStateFile
+	next     syncStateFile
+	full     bool
+	reused   int
+}
+
+type syncStateFile struct {
+	LastFullSync time.Time             `json:"lastFullSync"`
+	Users        map[string]cachedUser `json:"users"`
+}
+
+type cachedUser struct {
+	// ListingHash is the hash of the user as returned by the users listing.
+	ListingHash string `json:"listingHash"`
+	// Fingerprint is the hash of the user details and roles.
+	Fingerprint string           `json:"fingerprint"`
+	User        client.User      `json:"user"`
+	Roles       client.UserRoles `json:"roles"`
+}
+
+// begin loads the state of the previous sync and decides whether this sync is a full resync.
+func (s *userSyncState) begin(ctx context.Context) error {
+	l := ctxzap.Extract(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := syncStateFile{}
+	data, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("error reading sync state %s: %w", s.path, err)
+	default:
+		if err := json.Unmarshal(data, &previous); err != nil {
+			l.Warn("ignoring unreadable sync state, running a full sync", zap.String("path", s.path), zap.Error(err))
+			previous = syncStateFile{}
+		}
+	}
+
+	now := s.now()
+	s.previous = previous
+	s.full = previous.Users == nil || now.Sub(previous.LastFullSync) >= s.fullResyncInterval
+	s.next = syncStateFile{
+		LastFullSync: previous.LastFullSync,
+		Users:        make(map[string]cachedUser),
+	}
+	if s.full {
+		s.next.LastFullSync = now
+	}
+	s.reused = 0
+
+	l.Info("starting users sync", zap.Bool("full_resync", s.full), zap.Int("cached_users", len(previous.Users)))
+
+	return nil
+}
+
+// unchangedUser returns the details of the user from the previous sync when its listing did not change since.
+// They are only recorded for the next sync by store, along with the roles.
+func (s *userSyncState) unchangedUser(listed client.User) (client.User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.full {
+		return client.User{}, false
+	}
+
+	cached, ok := s.previous.Users[listed.Id]
+	if !ok || cached.ListingHash != hashJSON(listed) {
+		return client.User{}, false
+	}
+
+	s.reused++
+
+	return cached.User, true
+}
+
+// store records the details and roles of a user for the next sync.
+func (s *userSyncState) store(listed client.User, details client.User, roles client.UserRoles) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The state is written to disk, it must never hold a password.
+	details.Credentials.Password = ""
+
+	s.next.Users[listed.Id] = cachedUser{
+		ListingHash: hashJSON(listed),
+		Fingerprint: hashJSON(struct {
+			User  client.User      `json:"user"`
+			Roles client.UserRoles `json:"roles"`
+		}{details, roles}),
+		User:  details,
+		Roles: roles,
+	}
+}
+
+// save writes the state of this sync, users who no longer exist are dropped.
+func (s *userSyncState) save(ctx context.Context) error {
+	l := ctxzap.Extract(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := 0
+	for id, user := range s.next.Users {
+		if s.previous.Users[id].Fingerprint != user.Fingerprint {
+			changed++
+		}
+	}
+
+	data, err := json.Marshal(s.next)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so an interrupted sync never leaves a truncated state behind.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("error writing sync state %s: %w", s.path, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing sync state %s: %w", s.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing sync state %s: %w", s.path, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error writing sync state %s: %w", s.path, err)
+	}
+
+	l.Info("users sync state saved",
+		zap.Bool("full_resync", s.full),
+		zap.Int("fetched_users", len(s.next.Users)-s.reused),
+		zap.Int("reused_users", s.reused),
+		zap.Int("changed_users", changed),
+	)
+
+	return nil
+}
+
+func hashJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func newUserSyncState(path string, fullResyncInterval time.Duration) *userSyncState {
+	if fullResyncInterval <= 0 {
+		fullResyncInterval = defaultFullResyncInterval
+	}
+
+	return &userSyncState{
+		path:               path,
+		fullResyncInterval: fullResyncInterval,
+		now:                time.Now,
+	}
+}
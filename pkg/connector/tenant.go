@@ -0,0 +1,159 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// Users without activity during this window do not count as active seats.
+const seatActiveDays = 90
+
+type tenantBuilder struct {
+	resourceType *v2.ResourceType
+	client       client.FluidTopicsClientInterface
+	directory    *userDirectory
+	domain       string
+	now          func() time.Time
+}
+
+func (t *tenantBuilder) ResourceType(ctx context.Context) *v2.ResourceType { return tenantResourceType }
+
+// List returns the tenant, its profile holds the seat and license accounting computed from every user.
+func (t *tenantBuilder) List(ctx context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	seats, err := t.countSeats(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	tenantResource, err := parseIntoTenantResource(t.domain, seats)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return []*v2.Resource{tenantResource}, "", nil, nil
+}
+
+// Entitlements always returns an empty slice for the tenant.
+func (t *tenantBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Grants always returns an empty slice for the tenant.
+func (t *tenantBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// seatCounts holds the number of users of the tenant, in total and by activity, realm and role.
+type seatCounts struct {
+	total       int
+	active      int
+	inactive    int
+	realms      map[string]int
+	roleHolders map[string]int
+}
+
+// countSeats reads the details and roles of every user from the directory, the users sync reuses them. A user
+// holding a role through several role types is counted once for that role, since it still takes a single seat.
+func (t *tenantBuilder) countSeats(ctx context.Context) (*seatCounts, error) {
+	users, err := t.directory.users(ctx, directoryConsumerTenant)
+	if err != nil {
+		return nil, err
+	}
+
+	seats := &seatCounts{
+		realms:      make(map[string]int),
+		roleHolders: make(map[string]int),
+	}
+	for _, roleName := range allRoleNames() {
+		seats.roleHolders[roleName] = 0
+	}
+
+	threshold := t.now().AddDate(0, 0, -seatActiveDays)
+	for _, user := range users {
+		seats.total++
+		if user.LastLoginDate.After(threshold) {
+			seats.active++
+		} else {
+			seats.inactive++
+		}
+
+		var realms []string
+		for _, identifier := range user.AuthenticationIdentifiers {
+			if !slices.Contains(realms, identifier.Realm) {
+				realms = append(realms, identifier.Realm)
+				seats.realms[identifier.Realm]++
+			}
+		}
+
+		userRoles, err := t.directory.roles(ctx, user.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		var heldRoles []string
+		for _, roleName := range slices.Concat(userRoles.ManualRoles, userRoles.AuthenticationRoles, userRoles.DefaultRoles) {
+			if !slices.Contains(heldRoles, roleName) {
+				heldRoles = append(heldRoles, roleName)
+				seats.roleHolders[roleName]++
+			}
+		}
+	}
+
+	return seats, nil
+}
+
+func parseIntoTenantResource(domain string, seats *seatCounts) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"total_users":        seats.total,
+		"active_users":       seats.active,
+		"inactive_users":     seats.inactive,
+		"active_window_days": seatActiveDays,
+	}
+	for realm, count := range seats.realms {
+		profile[fmt.Sprintf("realm_users_%s", realm)] = count
+	}
+	for roleName, count := range seats.roleHolders {
+		profile[fmt.Sprintf("role_holders_%s", roleName)] = count
+	}
+
+	displayName := strings.TrimPrefix(domain, "https://")
+
+	ret, err := rs.NewResource(
+		displayName,
+		tenantResourceType,
+		displayName,
+		rs.WithAppTrait(rs.WithAppProfile(profile)),
+		rs.WithDescription(fmt.Sprintf("%d users, %d active in the last %d days", seats.total, seats.active, seatActiveDays)),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// newTenantBuilder returns a tenant builder counting the seats from the directory it shares with the user builder,
+// it has its own directory without incremental sync when nil.
+func newTenantBuilder(c client.FluidTopicsClientInterface, directory *userDirectory, domain string) *tenantBuilder {
+	if directory == nil {
+		directory = newUserDirectory(c, nil)
+	}
+
+	return &tenantBuilder{
+		resourceType: tenantResourceType,
+		client:       c,
+		directory:    directory,
+		domain:       domain,
+		now:          time.Now,
+	}
+}
@@ -0,0 +1,113 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantBuilderList(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	activeUser := client.User{
+		Id:            "active",
+		LastLoginDate: now.AddDate(0, 0, -5),
+		AuthenticationIdentifiers: []client.AuthenticationIdentifiers{
+			{Identifier: "active@x.com", Realm: "okta"},
+			{Identifier: "active", Realm: "internal"},
+		},
+	}
+	inactiveUser := client.User{
+		Id:            "inactive",
+		LastLoginDate: now.AddDate(0, 0, -200),
+		AuthenticationIdentifiers: []client.AuthenticationIdentifiers{
+			{Identifier: "inactive@x.com", Realm: "okta"},
+		},
+	}
+
+	mockClient := &client.MockFluidTopicsClient{}
+	tb := newTenantBuilder(mockClient, nil, "https://example.fluidtopics.net")
+	tb.now = func() time.Time { return now }
+
+	mockClient.On("ListUsers", mock.Anything).Return([]client.User{activeUser, inactiveUser}, "", annotations.Annotations{}, nil)
+	for _, user := range []client.User{activeUser, inactiveUser} {
+		mockClient.On("GetUserDetails", mock.Anything, user.Id).Return(user, annotations.Annotations{}, nil)
+	}
+	// A role held through several role types is a single seat.
+	mockClient.On("GetRolesByUserID", mock.Anything, "active").Return(client.UserRoles{
+		ManualRoles:         []string{"GENERATIVE_AI_USER"},
+		AuthenticationRoles: []string{"GENERATIVE_AI_USER"},
+		DefaultRoles:        []string{"PRINT_USER"},
+	}, annotations.Annotations{}, nil)
+	mockClient.On("GetRolesByUserID", mock.Anything, "inactive").Return(client.UserRoles{
+		DefaultRoles: []string{"PRINT_USER"},
+	}, annotations.Annotations{}, nil)
+
+	resources, _, _, err := tb.List(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.Equal(t, "example.fluidtopics.net", resources[0].Id.Resource)
+
+	appTrait, err := rs.GetAppTrait(resources[0])
+	require.NoError(t, err)
+	profile := appTrait.GetProfile().AsMap()
+
+	require.EqualValues(t, 2, profile["total_users"])
+	require.EqualValues(t, 1, profile["active_users"])
+	require.EqualValues(t, 1, profile["inactive_users"])
+	require.EqualValues(t, 2, profile["realm_users_okta"])
+	require.EqualValues(t, 1, profile["realm_users_internal"])
+	require.EqualValues(t, 1, profile["role_holders_GENERATIVE_AI_USER"])
+	require.EqualValues(t, 2, profile["role_holders_PRINT_USER"])
+	require.EqualValues(t, 0, profile["role_holders_ADMIN"])
+	mockClient.AssertExpectations(t)
+}
+
+func TestTenantBuilderSharesUsersWithUserBuilder(t *testing.T) {
+	ctx := context.Background()
+	user := client.User{Id: "user-1", LastLoginDate: time.Now()}
+	userRoles := client.UserRoles{ManualRoles: []string{"PRINT_USER"}}
+
+	// The SDK lists the resource types in no given order.
+	for _, tenantFirst := range []bool{true, false} {
+		mockClient := &client.MockFluidTopicsClient{}
+		directory := newUserDirectory(mockClient, nil)
+		ub := newUserBuilder(mockClient, false, directory, nil)
+		tb := newTenantBuilder(mockClient, directory, "https://example.fluidtopics.net")
+
+		// Each sync fetches every user once, whichever builder comes first.
+		mockClient.On("ListUsers", mock.Anything).Return([]client.User{user}, "", annotations.Annotations{}, nil).Twice()
+		mockClient.On("GetUserDetails", mock.Anything, user.Id).Return(user, annotations.Annotations{}, nil).Twice()
+		mockClient.On("GetRolesByUserID", mock.Anything, user.Id).Return(userRoles, annotations.Annotations{}, nil).Twice()
+
+		for i := 0; i < 2; i++ {
+			var users []*v2.Resource
+			listUsers := func() {
+				var err error
+				users, _, _, err = ub.List(ctx, nil, nil)
+				require.NoError(t, err)
+			}
+			if !tenantFirst {
+				listUsers()
+			}
+			_, _, _, err := tb.List(ctx, nil, nil)
+			require.NoError(t, err)
+			if tenantFirst {
+				listUsers()
+			}
+
+			grants, _, _, err := ub.Grants(ctx, users[0], nil)
+			require.NoError(t, err)
+			require.Len(t, grants, 1)
+		}
+		mockClient.AssertExpectations(t)
+	}
+}
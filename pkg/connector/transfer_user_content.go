@@ -0,0 +1,134 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The personal content handed over to the successor of a departing user.
+var transferredUserContents = []string{
+	client.UserContentPersonalBooks,
+	client.UserContentCollections,
+	client.UserContentSavedSearches,
+}
+
+var transferUserContentSchema = &v2.BatonActionSchema{
+	Name:        "transfer_user_content",
+	DisplayName: "Transfer user content",
+	Description: "Moves, or copies, the personal books, collections and saved searches of a departing user to a successor, " +
+		"so they are not lost when the account is deleted.",
+	Arguments: []*configv1.Field{
+		stringArgument("user_id", "User ID", "ID of the departing user.", true),
+		stringArgument("successor_id", "Successor ID", "ID of the user receiving the content.", true),
+		stringArgument("mode", "Mode", "Either move or copy the content, defaults to move.", false),
+		boolArgument("dry_run", "Dry run", "Only report the content that would be transferred. Enabled unless explicitly disabled.", true),
+	},
+	ReturnTypes: []*configv1.Field{
+		intArgument("count", "Count", "Number of items transferred, or that would be transferred.", true),
+	},
+}
+
+// transferUserContent hands the personal content of the user over to its successor. A failure on an item does not
+// stop the action, it is reported with the other results.
+func (a *customActions) transferUserContent(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	userID := stringArg(args, "user_id")
+	successorID := stringArg(args, "successor_id")
+	if userID == "" || successorID == "" {
+		return nil, nil, fmt.Errorf("user_id and successor_id are required")
+	}
+	if userID == successorID {
+		return nil, nil, fmt.Errorf("the successor must be another user")
+	}
+	mode := stringArg(args, "mode")
+	if mode == "" {
+		mode = client.UserContentTransferMove
+	}
+	if mode != client.UserContentTransferMove && mode != client.UserContentTransferCopy {
+		return nil, nil, fmt.Errorf("mode must be %s or %s", client.UserContentTransferMove, client.UserContentTransferCopy)
+	}
+	dryRun := boolArg(args, "dry_run", true)
+
+	// The successor must exist, otherwise every transfer would fail after the content was listed.
+	if _, _, err := a.client.GetUserDetails(ctx, successorID); err != nil {
+		return nil, nil, fmt.Errorf("error getting successor %s: %w", successorID, err)
+	}
+
+	transferred, failures, err := a.transferContent(ctx, userID, successorID, mode, dryRun)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ret, err := structpb.NewStruct(map[string]interface{}{
+		"user_id":      userID,
+		"successor_id": successorID,
+		"mode":         mode,
+		"dry_run":      dryRun,
+		"count":        len(transferred),
+		"items":        transferred,
+		"failures":     failures,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ret, nil, nil
+}
+
+// transferContent transfers every item of personal content of the user to the successor, and returns the reports
+// of the items transferred and of the items that failed.
+func (a *customActions) transferContent(
+	ctx context.Context,
+	userID string,
+	successorID string,
+	mode string,
+	dryRun bool,
+) ([]interface{}, []interface{}, error) {
+	l := ctxzap.Extract(ctx)
+
+	var transferred []interface{}
+	var failures []interface{}
+	for _, kind := range transferredUserContents {
+		items, _, err := a.client.ListUserContent(ctx, userID, kind)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error listing %s of user %s: %w", kind, userID, err)
+		}
+
+		for _, item := range items {
+			report := map[string]interface{}{
+				"kind":  kind,
+				"id":    item.Id,
+				"title": item.Title,
+			}
+
+			if !dryRun {
+				_, err := a.client.TransferUserContent(ctx, userID, kind, item.Id, client.UserContentTransferRequest{
+					TargetUserId: successorID,
+					Mode:         mode,
+				})
+				if err != nil {
+					l.Error("error transferring user content",
+						zap.String("user_id", userID),
+						zap.String("kind", kind),
+						zap.String("item_id", item.Id),
+						zap.Error(err),
+					)
+					report["error"] = err.Error()
+					failures = append(failures, report)
+					continue
+				}
+			}
+
+			transferred = append(transferred, report)
+		}
+	}
+
+	return transferred, failures, nil
+}
@@ -0,0 +1,76 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCustomActions_TransferUserContent(t *testing.T) {
+	ctx := context.Background()
+
+	newActions := func() (*customActions, *client.MockFluidTopicsClient) {
+		mockClient := &client.MockFluidTopicsClient{}
+		mockClient.On("GetUserDetails", mock.Anything, "successor").Return(client.User{Id: "successor"}, annotations.Annotations{}, nil)
+		mockClient.On("ListUserContent", mock.Anything, "leaver", client.UserContentPersonalBooks).
+			Return([]client.UserContentItem{{Id: "book-1", Title: "Install guide"}, {Id: "book-2", Title: "Notes"}}, annotations.Annotations{}, nil)
+		mockClient.On("ListUserContent", mock.Anything, "leaver", client.UserContentCollections).
+			Return([]client.UserContentItem{}, annotations.Annotations{}, nil)
+		mockClient.On("ListUserContent", mock.Anything, "leaver", client.UserContentSavedSearches).
+			Return([]client.UserContentItem{{Id: "search-1", Title: "release notes"}}, annotations.Annotations{}, nil)
+		return newCustomActions(mockClient, newManualRolesUpdater(mockClient)), mockClient
+	}
+
+	t.Run("Dry run only reports", func(t *testing.T) {
+		a, mockClient := newActions()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"user_id": "leaver", "successor_id": "successor"})
+		require.NoError(t, err)
+		res, _, err := a.transferUserContent(ctx, args)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, res.Fields["count"].GetNumberValue())
+		mockClient.AssertNotCalled(t, "TransferUserContent", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Transfers every item and reports the failures", func(t *testing.T) {
+		a, mockClient := newActions()
+		request := client.UserContentTransferRequest{TargetUserId: "successor", Mode: client.UserContentTransferCopy}
+		mockClient.On("TransferUserContent", mock.Anything, "leaver", client.UserContentPersonalBooks, "book-1", request).
+			Return(annotations.Annotations{}, nil).Once()
+		mockClient.On("TransferUserContent", mock.Anything, "leaver", client.UserContentPersonalBooks, "book-2", request).
+			Return(annotations.Annotations{}, errors.New("locked")).Once()
+		mockClient.On("TransferUserContent", mock.Anything, "leaver", client.UserContentSavedSearches, "search-1", request).
+			Return(annotations.Annotations{}, nil).Once()
+
+		args, err := structpb.NewStruct(map[string]interface{}{
+			"user_id":      "leaver",
+			"successor_id": "successor",
+			"mode":         client.UserContentTransferCopy,
+			"dry_run":      false,
+		})
+		require.NoError(t, err)
+		res, _, err := a.transferUserContent(ctx, args)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, res.Fields["count"].GetNumberValue())
+
+		failures := res.Fields["failures"].GetListValue().AsSlice()
+		require.Len(t, failures, 1)
+		require.Equal(t, "book-2", failures[0].(map[string]interface{})["id"])
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Rejects an unknown mode", func(t *testing.T) {
+		a, _ := newActions()
+
+		args, err := structpb.NewStruct(map[string]interface{}{"user_id": "leaver", "successor_id": "successor", "mode": "share"})
+		require.NoError(t, err)
+		_, _, err = a.transferUserContent(ctx, args)
+		require.Error(t, err)
+	})
+}
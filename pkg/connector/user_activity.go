@@ -0,0 +1,95 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+)
+
+var activityEventTypes = []string{
+	client.AnalyticsEventDocumentDisplay,
+	client.AnalyticsEventSearch,
+	client.AnalyticsEventDocumentExport,
+	client.AnalyticsEventGenerativeAI,
+}
+
+var activityMetrics = []string{
+	"documents_read",
+	"searches",
+	"pdf_exports",
+	"html_exports",
+	"generative_ai_queries",
+}
+
+var activityPeriods = []int{30, 90}
+
+// usersActivity holds the activity metrics of every user, keyed by user ID and then by profile field.
+type usersActivity map[string]map[string]int
+
+// profile returns the activity metrics of the user, every metric is present even when the user had no activity.
+func (a usersActivity) profile(userID string) map[string]interface{} {
+	profile := make(map[string]interface{})
+	for _, days := range activityPeriods {
+		for _, metric := range activityMetrics {
+			key := activityProfileKey(metric, days)
+			profile[key] = a[userID][key]
+		}
+	}
+	return profile
+}
+
+// fetchUsersActivity reads the activity metrics of all users with one analytics query per period.
+func fetchUsersActivity(ctx context.Context, c client.FluidTopicsClientInterface, now time.Time) (usersActivity, error) {
+	activity := make(usersActivity)
+
+	for _, days := range activityPeriods {
+		counts, _, err := c.GetUsersActivity(ctx, client.UsersActivityRequest{
+			StartDate:  now.AddDate(0, 0, -days),
+			EndDate:    now,
+			EventTypes: activityEventTypes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error getting users activity for the last %d days: %w", days, err)
+		}
+
+		for _, count := range counts {
+			metric := activityMetric(count)
+			if metric == "" {
+				continue
+			}
+			if activity[count.UserId] == nil {
+				activity[count.UserId] = make(map[string]int)
+			}
+			activity[count.UserId][activityProfileKey(metric, days)] += count.Count
+		}
+	}
+
+	return activity, nil
+}
+
+// activityMetric returns the metric an activity count contributes to, or an empty string when it is not tracked.
+func activityMetric(count client.UserActivityCount) string {
+	switch count.Type {
+	case client.AnalyticsEventDocumentDisplay:
+		return "documents_read"
+	case client.AnalyticsEventSearch:
+		return "searches"
+	case client.AnalyticsEventGenerativeAI:
+		return "generative_ai_queries"
+	case client.AnalyticsEventDocumentExport:
+		switch strings.ToLower(count.Format) {
+		case "pdf":
+			return "pdf_exports"
+		case "html":
+			return "html_exports"
+		}
+	}
+	return ""
+}
+
+func activityProfileKey(metric string, days int) string {
+	return fmt.Sprintf("%s_%dd", metric, days)
+}
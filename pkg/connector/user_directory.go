@@ -0,0 +1,137 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+)
+
+const (
+	directoryConsumerUsers  = "users"
+	directoryConsumerTenant = "tenant"
+	directoryConsumerGroups = "groups"
+)
+
+// userDirectory fetches the details and roles of the users once per sync, for the users, the tenant and the groups
+// syncers.
+// The SDK lists the resource types in no given order, so whichever syncer comes first fetches them and the other
+// reuses them. Each consumer reads the users of a sync once, its next read starts a new sync.
+type userDirectory struct {
+	client    client.FluidTopicsClientInterface
+	syncState *userSyncState
+
+	mu        sync.Mutex
+	loaded    []client.User
+	consumers map[string]bool
+	userRoles map[string]client.UserRoles
+}
+
+// users returns the details of every user for the sync of the consumer. On incremental syncs the roles are fetched
+// along with the details, otherwise they are fetched by roles when first needed.
+func (d *userDirectory) users(ctx context.Context, consumer string) ([]client.User, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.loaded != nil && !d.consumers[consumer] {
+		d.consumers[consumer] = true
+		return d.loaded, nil
+	}
+
+	listed, _, _, err := d.client.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.syncState != nil {
+		if err := d.syncState.begin(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	userRoles := make(map[string]client.UserRoles)
+	details := make([]client.User, 0, len(listed))
+	for _, user := range listed {
+		userDetails, err := d.getUserDetails(ctx, user, userRoles)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, userDetails)
+	}
+
+	if d.syncState != nil {
+		if err := d.syncState.save(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	d.loaded = details
+	d.consumers = map[string]bool{consumer: true}
+	d.userRoles = userRoles
+
+	return details, nil
+}
+
+// getUserDetails returns the details of the user. On incremental syncs they are reused from the previous sync
+// when the listing of the user did not change, while its roles are fetched again: a role change does not show in the
+// listing.
+func (d *userDirectory) getUserDetails(ctx context.Context, user client.User, userRoles map[string]client.UserRoles) (client.User, error) {
+	if d.syncState == nil {
+		details, _, err := d.client.GetUserDetails(ctx, user.Id)
+		if err != nil {
+			return client.User{}, fmt.Errorf("error getting user details %s: %w", user.Id, err)
+		}
+		return details, nil
+	}
+
+	details, ok := d.syncState.unchangedUser(user)
+	if !ok {
+		var err error
+		details, _, err = d.client.GetUserDetails(ctx, user.Id)
+		if err != nil {
+			return client.User{}, fmt.Errorf("error getting user details %s: %w", user.Id, err)
+		}
+	}
+
+	roles, _, err := d.client.GetRolesByUserID(ctx, user.Id)
+	if err != nil {
+		return client.User{}, err
+	}
+	d.syncState.store(user, details, roles)
+	userRoles[user.Id] = roles
+
+	return details, nil
+}
+
+// roles returns the roles of the user, fetched at most once per sync.
+func (d *userDirectory) roles(ctx context.Context, userID string) (client.UserRoles, error) {
+	d.mu.Lock()
+	roles, ok := d.userRoles[userID]
+	d.mu.Unlock()
+	if ok {
+		return roles, nil
+	}
+
+	roles, _, err := d.client.GetRolesByUserID(ctx, userID)
+	if err != nil {
+		return client.UserRoles{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.userRoles == nil {
+		d.userRoles = make(map[string]client.UserRoles)
+	}
+	d.userRoles[userID] = roles
+
+	return roles, nil
+}
+
+// newUserDirectory returns a user directory, syncState is nil unless incremental syncs are enabled.
+func newUserDirectory(c client.FluidTopicsClientInterface, syncState *userSyncState) *userDirectory {
+	return &userDirectory{
+		client:    c,
+		syncState: syncState,
+	}
+}
@@ -0,0 +1,163 @@
+package connector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const (
+	userDumpAssetPrefix      = "user_dump:"
+	userDumpAssetContentType = "application/json"
+)
+
+// userDumps serves the personal data export of the users as assets.
+type userDumps struct {
+	client client.FluidTopicsClientInterface
+}
+
+// Asset streams the data dump of the user referenced by the asset as it is received, with its credentials redacted.
+// The dump includes the personal books, bookmarks and saved searches of the user.
+func (d *userDumps) Asset(ctx context.Context, asset *v2.AssetRef) (string, io.ReadCloser, error) {
+	userID, ok := strings.CutPrefix(asset.GetId(), userDumpAssetPrefix)
+	if !ok || userID == "" {
+		return "", nil, fmt.Errorf("unsupported asset %q", asset.GetId())
+	}
+
+	dump, err := d.client.StreamUserDump(ctx, userID)
+	if err != nil {
+		return "", nil, fmt.Errorf("error getting data dump of user %s: %w", userID, err)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		defer dump.Close()
+
+		err := writeUserDump(writer, dump)
+		if err != nil {
+			ctxzap.Extract(ctx).Error("error streaming user data dump", zap.String("user_id", userID), zap.Error(err))
+		}
+		_ = writer.CloseWithError(err)
+	}()
+
+	return userDumpAssetContentType, reader, nil
+}
+
+// writeUserDump copies the dump token by token, so a large dump is never held in memory.
+func writeUserDump(w io.Writer, dump io.Reader) error {
+	dec := json.NewDecoder(dump)
+	dec.UseNumber()
+
+	bw := bufio.NewWriter(w)
+	if err := copyRedacted(bw, dec); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// copyRedacted copies the next JSON value of the decoder, replacing the value of every credential key.
+func copyRedacted(w *bufio.Writer, dec *json.Decoder) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		if err := w.WriteByte('{'); err != nil {
+			return err
+		}
+		for i := 0; dec.More(); i++ {
+			token, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, ok := token.(string)
+			if !ok {
+				return fmt.Errorf("unexpected object key %v", token)
+			}
+
+			if i > 0 {
+				if err := w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			if err := writeJSON(w, key); err != nil {
+				return err
+			}
+			if err := w.WriteByte(':'); err != nil {
+				return err
+			}
+
+			if client.IsCredentialKey(key) {
+				var credential json.RawMessage
+				if err := dec.Decode(&credential); err != nil {
+					return err
+				}
+				if err := writeJSON(w, client.RedactedValue); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := copyRedacted(w, dec); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		return w.WriteByte('}')
+
+	case json.Delim('['):
+		if err := w.WriteByte('['); err != nil {
+			return err
+		}
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				if err := w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			if err := copyRedacted(w, dec); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		return w.WriteByte(']')
+
+	default:
+		// A string, a json.Number, a bool or null.
+		return writeJSON(w, token)
+	}
+}
+
+// writeJSON writes the JSON encoding of a scalar value.
+func writeJSON(w *bufio.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// userDumpAssetId returns the ID of the asset holding the data dump of the user.
+func userDumpAssetId(userID string) string {
+	return userDumpAssetPrefix + userID
+}
+
+func newUserDumps(c client.FluidTopicsClientInterface) *userDumps {
+	return &userDumps{
+		client: c,
+	}
+}
@@ -0,0 +1,75 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-fluid-topics/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserDumpsAsset(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Streams the dump with the credentials redacted", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		d := newUserDumps(mockClient)
+
+		mockClient.On("StreamUserDump", mock.Anything, "user-1").Return(io.NopCloser(strings.NewReader(`{
+			"user": {"id":"user-1","credentials":{"login":"a@x.com","password":"secret"},"accessToken":"secret-token"},
+			"personalBooks": [{"id":"book-1","title":"Notes","pages":12345678901234567890}],
+			"bookmarks": [],
+			"savedSearches": [{"query":"install","apiKey":"k","enabled":true,"alert":null}]
+		}`)), nil)
+
+		contentType, reader, err := d.Asset(ctx, &v2.AssetRef{Id: userDumpAssetId("user-1")})
+		require.NoError(t, err)
+		require.Equal(t, "application/json", contentType)
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		require.NotContains(t, string(data), "secret")
+
+		var dump map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &dump))
+		require.Len(t, dump, 4)
+
+		credentials := dump["user"].(map[string]interface{})["credentials"].(map[string]interface{})
+		require.Equal(t, "a@x.com", credentials["login"])
+		require.Equal(t, client.RedactedValue, credentials["password"])
+		require.Equal(t, client.RedactedValue, dump["user"].(map[string]interface{})["accessToken"])
+		require.Equal(t, client.RedactedValue, dump["savedSearches"].([]interface{})[0].(map[string]interface{})["apiKey"])
+		require.Equal(t, "Notes", dump["personalBooks"].([]interface{})[0].(map[string]interface{})["title"])
+
+		// Values are copied as they are received.
+		require.Contains(t, string(data), `"pages":12345678901234567890`)
+		require.Contains(t, string(data), `"enabled":true,"alert":null`)
+	})
+
+	t.Run("Fails the stream on a truncated dump", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		d := newUserDumps(mockClient)
+
+		mockClient.On("StreamUserDump", mock.Anything, "user-1").
+			Return(io.NopCloser(strings.NewReader(`{"user":{"id":"user-1"},"bookmarks":[{"id"`)), nil)
+
+		_, reader, err := d.Asset(ctx, &v2.AssetRef{Id: userDumpAssetId("user-1")})
+		require.NoError(t, err)
+
+		_, err = io.ReadAll(reader)
+		require.Error(t, err)
+	})
+
+	t.Run("Rejects unknown assets", func(t *testing.T) {
+		d := newUserDumps(&client.MockFluidTopicsClient{})
+
+		_, _, err := d.Asset(ctx, &v2.AssetRef{Id: "icon:user-1"})
+		require.Error(t, err)
+	})
+}
@@ -10,13 +10,15 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
-	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
 )
 
 type userBuilder struct {
-	resourceType *v2.ResourceType
-	client       client.FluidTopicsClientInterface
+	resourceType    *v2.ResourceType
+	client          client.FluidTopicsClientInterface
+	activityMetrics bool
+	directory       *userDirectory
+	metrics         *syncMetrics
 }
 
 func (u *userBuilder) ResourceType(context.Context) *v2.ResourceType {
@@ -28,23 +30,29 @@ func (u *userBuilder) ResourceType(context.Context) *v2.ResourceType {
 func (u *userBuilder) List(ctx context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
 	var resources []*v2.Resource
 
-	users, _, _, err := u.client.ListUsers(ctx)
+	details, err := u.directory.users(ctx, directoryConsumerUsers)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	for _, user := range users {
-		userID := user.Id
-		userCopy, _, err := u.client.GetUserDetails(ctx, userID)
+	var activity usersActivity
+	if u.activityMetrics {
+		activity, err = fetchUsersActivity(ctx, u.client, time.Now())
 		if err != nil {
-			return nil, "", nil, fmt.Errorf("error getting user details %s: %w", userID, err)
+			return nil, "", nil, err
 		}
-		userResource, err := parseIntoUserResource(&userCopy)
+	}
+
+	// Duplicates are found from the details, the listing does not hold the authentication identifiers.
+	duplicates := findDuplicateUsers(details)
+	for i := range details {
+		userResource, err := parseIntoUserResource(&details[i], activity, duplicates)
 		if err != nil {
 			return nil, "", nil, err
 		}
 		resources = append(resources, userResource)
 	}
+	u.metrics.usersProcessed.Add(ctx, int64(len(resources)), nil)
 
 	return resources, "", nil, nil
 }
@@ -60,7 +68,7 @@ func (u *userBuilder) Grants(ctx context.Context, res *v2.Resource, _ *paginatio
 	var grants []*v2.Grant
 	var userID = res.Id.Resource
 
-	user, _, err := u.client.GetRolesByUserID(ctx, userID)
+	user, err := u.directory.roles(ctx, userID)
 	if err != nil {
 		return nil, "", nil, err
 	}
@@ -76,20 +84,12 @@ func (u *userBuilder) Grants(ctx context.Context, res *v2.Resource, _ *paginatio
 
 	for _, roleTypeData := range rolesTypes {
 		for _, roleName := range roleTypeData.RoleList {
-			description := getRoleDescription(roleName)
-
-			roleResource := &v2.Resource{
-				Id: &v2.ResourceId{
-					ResourceType: roleResourceType.Id,
-					Resource:     fmt.Sprintf("%s:%s", roleTypeData.RoleType, roleName),
-				},
-				DisplayName: fmt.Sprintf("%s:%s", roleTypeData.RoleType, roleName),
-				Description: description,
-			}
-
-			roleGrant := grant.NewGrant(roleResource, permissionName, res)
+			roleGrant := newRoleGrant(roleTypeData.RoleType, roleName, res)
 			grants = append(grants, roleGrant)
 		}
+		if len(roleTypeData.RoleList) > 0 {
+			u.metrics.grantsEmitted.Add(ctx, int64(len(roleTypeData.RoleList)), map[string]string{"role_type": roleTypeData.RoleType})
+		}
 	}
 	return grants, "", nil, nil
 }
@@ -112,21 +112,22 @@ func (u *userBuilder) CreateAccount(
 	if err != nil {
 		return nil, nil, annotations.Annotations{}, err
 	}
+	ctx = client.WithAuditTask(ctx, auditTaskCreateAccount, newUser.EmailAddress)
 
 	_, err = u.client.CreateUser(ctx, *newUser)
 	if err != nil {
 		return nil, nil, annotations.Annotations{}, err
 	}
 
+	// The password is only returned as plaintext data, never as part of the resource.
 	userResource, err := parseIntoUserResource(
 		&client.User{
 			DisplayName: newUser.Name,
 			Email:       newUser.EmailAddress,
-			Credentials: client.Credentials{
-				Login:    newUser.EmailAddress,
-				Password: newUser.Password,
-			},
+			Credentials: client.Credentials{Login: newUser.EmailAddress},
 		},
+		nil,
+		nil,
 	)
 	if err != nil {
 		return nil, nil, nil, err
@@ -170,7 +171,9 @@ func createNewUserInfo(accountInfo *v2.AccountInfo, credentialOptions *v2.Creden
 	return newUser, nil
 }
 
-func parseIntoUserResource(user *client.User) (*v2.Resource, error) {
+// parseIntoUserResource adds the activity metrics of the user to its profile when activity is not nil, and flags
+// the users it likely duplicates.
+func parseIntoUserResource(user *client.User, activity usersActivity, duplicates duplicateUsers) (*v2.Resource, error) {
 	var userStatus = v2.UserTrait_Status_STATUS_ENABLED
 
 	var realm string
@@ -185,6 +188,19 @@ func parseIntoUserResource(user *client.User) (*v2.Resource, error) {
 		"creation_date":        user.CreationDate.Format(time.RFC3339),
 		"authentication_realm": realm,
 	}
+	if user.Id != "" {
+		profile["data_dump_asset_id"] = userDumpAssetId(user.Id)
+	}
+
+	if duplicateIDs := duplicates[user.Id]; len(duplicateIDs) > 0 {
+		profile["possible_duplicate_ids"] = toList(duplicateIDs)
+	}
+
+	if activity != nil {
+		for key, value := range activity.profile(user.Id) {
+			profile[key] = value
+		}
+	}
 
 	displayName := user.DisplayName
 
@@ -204,6 +220,11 @@ func parseIntoUserResource(user *client.User) (*v2.Resource, error) {
 		userResourceType,
 		user.Id,
 		userTraits,
+		rs.WithAnnotation(
+			&v2.ChildResourceType{ResourceTypeId: personalBookResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: collectionResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: savedSearchResourceType.Id},
+		),
 	)
 
 	if err != nil {
@@ -213,9 +234,26 @@ func parseIntoUserResource(user *client.User) (*v2.Resource, error) {
 	return ret, nil
 }
 
-func newUserBuilder(c client.FluidTopicsClientInterface) *userBuilder {
+// newUserBuilder returns a user builder. The users are fetched through the directory it shares with the tenant
+// builder, it has its own directory without incremental sync when nil.
+func newUserBuilder(
+	c client.FluidTopicsClientInterface,
+	activityMetrics bool,
+	directory *userDirectory,
+	m *syncMetrics,
+) *userBuilder {
+	if m == nil {
+		m = newSyncMetrics(context.Background(), nil)
+	}
+	if directory == nil {
+		directory = newUserDirectory(c, nil)
+	}
+
 	return &userBuilder{
-		resourceType: userResourceType,
-		client:       c,
+		resourceType:    userResourceType,
+		client:          c,
+		activityMetrics: activityMetrics,
+		directory:       directory,
+		metrics:         m,
 	}
 }
@@ -2,12 +2,15 @@ package connector
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/conductorone/baton-fluid-topics/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -15,7 +18,7 @@ import (
 func TestUserBuilder_WithMockClient(t *testing.T) {
 	ctx := context.Background()
 	mockClient := &client.MockFluidTopicsClient{}
-	ub := newUserBuilder(mockClient)
+	ub := newUserBuilder(mockClient, false, nil, nil)
 
 	testUser := client.User{
 		Id:           "a061ccd9-3b8d-4f73-8d21-d045b3680a9d",
@@ -73,4 +76,83 @@ func TestUserBuilder_WithMockClient(t *testing.T) {
 
 		require.ElementsMatch(t, expectedEntitlementIDs, actualEntitlementIDs)
 	})
+
+	t.Run("List should add activity metrics when enabled", func(t *testing.T) {
+		mockClient := &client.MockFluidTopicsClient{}
+		ub := newUserBuilder(mockClient, true, nil, nil)
+
+		mockClient.On("ListUsers", mock.Anything).Return([]client.User{testUser}, "", annotations.Annotations{}, nil)
+		mockClient.On("GetUserDetails", ctx, testUser.Id).Return(testUser, annotations.Annotations{}, nil)
+		mockClient.On("GetUsersActivity", ctx, mock.Anything).Return([]client.UserActivityCount{
+			{UserId: testUser.Id, Type: client.AnalyticsEventDocumentDisplay, Count: 12},
+			{UserId: testUser.Id, Type: client.AnalyticsEventDocumentExport, Format: "PDF", Count: 2},
+		}, annotations.Annotations{}, nil).Twice()
+
+		users, _, _, err := ub.List(ctx, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+
+		userTrait, err := rs.GetUserTrait(users[0])
+		require.NoError(t, err)
+		profile := userTrait.Profile.AsMap()
+		require.EqualValues(t, 12, profile["documents_read_30d"])
+		require.EqualValues(t, 2, profile["pdf_exports_90d"])
+		require.EqualValues(t, 0, profile["html_exports_30d"])
+		require.EqualValues(t, 0, profile["generative_ai_queries_90d"])
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("Incremental List only fetches the users whose listing changed", func(t *testing.T) {
+		statePath := filepath.Join(t.TempDir(), "state.json")
+		syncState := newUserSyncState(statePath, 24*time.Hour)
+		now := time.Now()
+		syncState.now = func() time.Time { return now }
+
+		listed := client.User{Id: testUser.Id, DisplayName: testUser.DisplayName}
+		userRoles := client.UserRoles{ManualRoles: []string{"PRINT_USER"}}
+
+		mockClient := &client.MockFluidTopicsClient{}
+		ub := newUserBuilder(mockClient, false, newUserDirectory(mockClient, syncState), nil)
+		mockClient.On("ListUsers", mock.Anything).Return([]client.User{listed}, "", annotations.Annotations{}, nil).Twice()
+		mockClient.On("GetUserDetails", ctx, testUser.Id).Return(testUser, annotations.Annotations{}, nil).Once()
+		mockClient.On("GetRolesByUserID", ctx, testUser.Id).Return(userRoles, annotations.Annotations{}, nil).Once()
+		// A role changed in the admin UI does not change the listing, the roles are fetched on every sync.
+		changedRoles := client.UserRoles{ManualRoles: []string{"PRINT_USER", "KHUB_ADMIN"}}
+		mockClient.On("GetRolesByUserID", ctx, testUser.Id).Return(changedRoles, annotations.Annotations{}, nil).Once()
+
+		// The first sync fetches everything, the second one reuses the details from the state.
+		for i, expectedGrants := range []int{1, 2} {
+			users, _, _, err := ub.List(ctx, nil, nil)
+			require.NoError(t, err)
+			require.Len(t, users, 1)
+
+			grants, _, _, err := ub.Grants(ctx, users[0], nil)
+			require.NoError(t, err)
+			require.Len(t, grants, expectedGrants, "sync %d", i)
+		}
+		mockClient.AssertExpectations(t)
+
+		state, err := os.ReadFile(statePath)
+		require.NoError(t, err)
+		require.NotContains(t, string(state), testUser.Credentials.Password)
+
+		// A changed listing fetches the user again.
+		listed.DisplayName = "Renamed User"
+		mockClient.On("ListUsers", mock.Anything).Return([]client.User{listed}, "", annotations.Annotations{}, nil).Once()
+		mockClient.On("GetUserDetails", ctx, testUser.Id).Return(testUser, annotations.Annotations{}, nil).Once()
+		mockClient.On("GetRolesByUserID", ctx, testUser.Id).Return(userRoles, annotations.Annotations{}, nil).Once()
+		_, _, _, err = ub.List(ctx, nil, nil)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+
+		// Once the full resync interval elapsed every user is fetched again.
+		now = now.Add(25 * time.Hour)
+		mockClient.On("ListUsers", mock.Anything).Return([]client.User{listed}, "", annotations.Annotations{}, nil).Once()
+		mockClient.On("GetUserDetails", ctx, testUser.Id).Return(testUser, annotations.Annotations{}, nil).Once()
+		mockClient.On("GetRolesByUserID", ctx, testUser.Id).Return(userRoles, annotations.Annotations{}, nil).Once()
+		_, _, _, err = ub.List(ctx, nil, nil)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
 }